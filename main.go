@@ -2,7 +2,12 @@ package main
 
 import (
 	"bookcabin-app-go/src/libs"
+	"bookcabin-app-go/src/providers"
 	"bookcabin-app-go/src/routes"
+	"bookcabin-app-go/src/services"
+	"bookcabin-app-go/src/utils"
+	"context"
+	"log"
 
 	"github.com/gin-gonic/gin"
 )
@@ -10,6 +15,21 @@ import (
 func main() {
 	libs.LoadEnv()
 
+	if err := utils.CheckOfferTokenSecret(); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := utils.CheckFareRules(); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := providers.CheckAncillaryCatalogues(); err != nil {
+		log.Fatal(err)
+	}
+
+	// release expired seat holds with their providers in the background
+	go services.NewSeatHoldService().RunSeatHoldReaper(context.Background())
+
 	router := gin.Default()
 	routes.RegisterRoutes(router)
 
@@ -7,20 +7,29 @@ const (
 	LA_DateTimeLayout = "2006-01-02T15:04:05"
 )
 
+const (
+	DefaultCurrency = "IDR"
+	DefaultLocale   = "id"
+	DefaultChannel  = "web"
+)
+
 var (
-	Cities = map[string]string{
-		"CGK": "Jakarta",
-		"DPS": "Denpasar",
-		"SOC": "Kabupaten Boyolali",
-		"SUB": "Surabaya",
-		"UPG": "Kabupaten Maros",
+	// number of minor unit digits shown for each supported currency
+	CurrencyDecimals = map[string]int{
+		"IDR": 0,
+		"USD": 2,
+		"SGD": 2,
+		"MYR": 2,
 	}
 
-	Timezones = map[string]string{
-		"CGK": "Asia/Jakarta",
-		"DPS": "Asia/Makassar",
-		"SOC": "Asia/Jakarta",
-		"SUB": "Asia/Jakarta",
-		"UPG": "Asia/Jakarta",
+	CurrencySymbols = map[string]string{
+		"IDR": "Rp",
+		"USD": "US$",
+		"SGD": "S$",
+		"MYR": "RM",
 	}
+
+	SupportedLocales = []string{"id", "en"}
+
+	SalesChannels = []string{"web", "mobile", "agent"}
 )
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"bookcabin-app-go/src/models"
+	"bookcabin-app-go/src/services"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultAirportSearchLimit = 10
+	maxAirportSearchLimit     = 50
+)
+
+func SearchAirports(ctx *gin.Context) {
+	query := strings.TrimSpace(ctx.Query("q"))
+	if query == "" {
+		respondValidationProblem(ctx, invalidParams{{Name: "q", Code: codeRequired, Reason: "is required"}})
+		return
+	}
+
+	country := strings.ToUpper(strings.TrimSpace(ctx.Query("country")))
+
+	limit := defaultAirportSearchLimit
+	if rawLimit := ctx.Query("limit"); rawLimit != "" {
+		parsedLimit, err := strconv.Atoi(rawLimit)
+		if err != nil || parsedLimit <= 0 {
+			respondValidationProblem(ctx, invalidParams{{Name: "limit", Code: codeOutOfRange, Reason: "must be a positive number"}})
+			return
+		}
+		limit = min(parsedLimit, maxAirportSearchLimit)
+	}
+
+	airportService := services.NewAirportService()
+
+	ctx.JSON(http.StatusOK, models.AirportSearchResponse{
+		Query:   query,
+		Country: country,
+		Results: airportService.Search(query, country, limit),
+	})
+}
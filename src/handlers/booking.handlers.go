@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"bookcabin-app-go/src/models"
+	"bookcabin-app-go/src/services"
+	"bookcabin-app-go/src/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxPassengerAgeInYears = 120
+)
+
+var (
+	passengerNamePattern  = regexp.MustCompile(`^[A-Z][A-Z '\-]{0,49}$`)
+	documentNumberPattern = regexp.MustCompile(`^[A-Z0-9]{5,20}$`)
+	countryCodePattern    = regexp.MustCompile(`^[A-Z]{2}$`)
+	phonePattern          = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+	passengerTitles = []string{"MR", "MRS", "MS", "MSTR", "MISS"}
+	documentTypes   = []string{"passport", "national_id"}
+)
+
+func CreateBooking(ctx *gin.Context) {
+	var req models.BookingRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondBindingProblem(ctx, err)
+		return
+	}
+
+	offer, params := parseOfferToken("offerToken", req.OfferToken)
+	locale := validateLocale(&params, req.Locale, ctx.GetHeader("Accept-Language"))
+
+	if len(params) == 0 && len(req.Passengers) != offer.Passengers {
+		params.add("passengers", codeInvalidValue, fmt.Sprintf("must list the %d passengers of the offer", offer.Passengers))
+	}
+
+	documentNumbers := make(map[string]bool)
+	for i := range req.Passengers {
+		field := fmt.Sprintf("passengers[%d]", i)
+		validateAndNormalizePassenger(&params, field, &req.Passengers[i], offer.DepartureDate)
+
+		// every passenger travels on their own document
+		if documentNumber := req.Passengers[i].DocumentNumber; documentNumber != "" {
+			if documentNumbers[documentNumber] {
+				params.add(field+".documentNumber", codeInvalidValue, "is already used by another passenger")
+			}
+			documentNumbers[documentNumber] = true
+		}
+	}
+	validateAndNormalizeContact(&params, &req.Contact)
+
+	if len(params) > 0 {
+		respondValidationProblem(ctx, params)
+		return
+	}
+
+	bookingService := services.NewBookingService()
+	booking, err := bookingService.Create(ctx, offer, req, locale)
+
+	switch {
+	case errors.Is(err, services.ErrOfferNotFound):
+		respondNotFoundProblem(ctx, "flight "+offer.FlightID+" is no longer offered by "+offer.Provider)
+	case errors.Is(err, services.ErrOfferUnavailable):
+		respondConflictProblem(ctx, problemTypeOfferUnavailable, "Offer is no longer available", err.Error())
+	case errors.Is(err, services.ErrPriceChanged):
+		respondConflictProblem(ctx, problemTypePriceChanged, "Offer price has changed", err.Error())
+	case errors.Is(err, services.ErrPromoUsageLimitReached):
+		respondConflictProblem(ctx, problemTypePromoUnavailable, "Promo code is no longer available", err.Error())
+	case err != nil:
+		respondInternalProblem(ctx, err)
+	default:
+		utils.RedactBooking(&booking, isInternalRequest(ctx))
+		ctx.JSON(http.StatusCreated, booking)
+	}
+}
+
+func GetBooking(ctx *gin.Context) {
+	bookingService := services.NewBookingService()
+
+	booking, internal, ok := getAuthorizedBooking(ctx, bookingService)
+	if !ok {
+		return
+	}
+
+	utils.RedactBooking(&booking, internal)
+	ctx.JSON(http.StatusOK, booking)
+}
+
+// Bookings are read and cancelled with the access token issued when they were
+// created, sent in X-Booking-Token, or by internal callers. A wrong token gets
+// the same 404 as an unknown booking, so booking IDs can't be probed.
+func getAuthorizedBooking(ctx *gin.Context, bookingService *services.BookingService) (models.Booking, bool, bool) {
+	internal := isInternalRequest(ctx)
+	accessToken := ctx.GetHeader("X-Booking-Token")
+
+	if !internal && accessToken == "" {
+		respondForbiddenProblem(ctx, "bookings need their access token in X-Booking-Token or a valid X-Internal-Api-Key")
+		return models.Booking{}, internal, false
+	}
+
+	booking, err := bookingService.Get(ctx, ctx.Param("id"))
+	if err == nil && !internal && !services.VerifyBookingAccessToken(booking, accessToken) {
+		err = services.ErrBookingNotFound
+	}
+
+	switch {
+	case errors.Is(err, services.ErrBookingNotFound):
+		respondNotFoundProblem(ctx, "booking "+ctx.Param("id")+" does not exist")
+	case err != nil:
+		respondInternalProblem(ctx, err)
+	default:
+		return booking, internal, true
+	}
+	return models.Booking{}, internal, false
+}
+
+// Customer service lookup by contact email, only for internal callers
+func SearchBookings(ctx *gin.Context) {
+	if !isInternalRequest(ctx) {
+		respondForbiddenProblem(ctx, "looking up bookings by email needs a valid X-Internal-Api-Key")
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(ctx.Query("email")))
+	if email == "" {
+		respondValidationProblem(ctx, invalidParams{{Name: "email", Code: codeRequired, Reason: "is required"}})
+		return
+	}
+
+	bookingService := services.NewBookingService()
+	bookings, err := bookingService.FindByEmail(ctx, email)
+	if err != nil {
+		respondInternalProblem(ctx, err)
+		return
+	}
+
+	for i := range bookings {
+		utils.RedactBooking(&bookings[i], true)
+	}
+
+	ctx.JSON(http.StatusOK, models.BookingSearchResponse{
+		Email:   email,
+		Results: bookings,
+	})
+}
+
+func CancelBooking(ctx *gin.Context) {
+	var req models.CancelBookingRequest
+
+	// the body is optional, it only carries a reason
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			respondBindingProblem(ctx, err)
+			return
+		}
+	}
+
+	bookingService := services.NewBookingService()
+
+	_, internal, ok := getAuthorizedBooking(ctx, bookingService)
+	if !ok {
+		return
+	}
+
+	booking, err := bookingService.Cancel(ctx, ctx.Param("id"), strings.TrimSpace(req.Reason))
+
+	switch {
+	case errors.Is(err, services.ErrBookingNotFound):
+		respondNotFoundProblem(ctx, "booking "+ctx.Param("id")+" does not exist")
+	case errors.Is(err, services.ErrBookingNotCancellable):
+		respondConflictProblem(ctx, problemTypeNotCancellable, "Booking cannot be cancelled", err.Error())
+	case err != nil:
+		respondInternalProblem(ctx, err)
+	default:
+		utils.RedactBooking(&booking, internal)
+		ctx.JSON(http.StatusOK, booking)
+	}
+}
+
+// Check a passenger against what airlines accept on a ticket, normalizing
+// names, codes and document numbers to uppercase
+func validateAndNormalizePassenger(params *invalidParams, field string, passenger *models.PassengerDetails, departureDate string) {
+	passenger.Title = strings.ToUpper(strings.TrimSpace(passenger.Title))
+	passenger.FirstName = strings.ToUpper(strings.TrimSpace(passenger.FirstName))
+	passenger.LastName = strings.ToUpper(strings.TrimSpace(passenger.LastName))
+	passenger.Nationality = strings.ToUpper(strings.TrimSpace(passenger.Nationality))
+	passenger.DocumentType = strings.ToLower(strings.TrimSpace(passenger.DocumentType))
+	passenger.DocumentNumber = strings.ToUpper(strings.TrimSpace(passenger.DocumentNumber))
+
+	if passenger.Title != "" && !slices.Contains(passengerTitles, passenger.Title) {
+		params.add(field+".title", codeInvalidValue, "must be one of "+strings.Join(passengerTitles, ", "))
+	}
+
+	validateRequiredPattern(params, field+".firstName", passenger.FirstName, passengerNamePattern, "must only contain latin letters, spaces, apostrophes and hyphens")
+	validateRequiredPattern(params, field+".lastName", passenger.LastName, passengerNamePattern, "must only contain latin letters, spaces, apostrophes and hyphens")
+	validateRequiredPattern(params, field+".nationality", passenger.Nationality, countryCodePattern, "must be an ISO 3166 alpha-2 country code")
+	validateRequiredPattern(params, field+".documentNumber", passenger.DocumentNumber, documentNumberPattern, "must be 5 to 20 letters or digits")
+
+	if passenger.DateOfBirth == "" {
+		params.add(field+".dateOfBirth", codeRequired, "is required")
+	} else if dateOfBirth, err := time.Parse(time.DateOnly, passenger.DateOfBirth); err != nil {
+		params.add(field+".dateOfBirth", codeInvalidFormat, "must be a date in YYYY-MM-DD format")
+	} else if dateOfBirth.After(time.Now()) || dateOfBirth.Before(time.Now().AddDate(-maxPassengerAgeInYears, 0, 0)) {
+		params.add(field+".dateOfBirth", codeOutOfRange, fmt.Sprintf("must be in the past %d years", maxPassengerAgeInYears))
+	}
+
+	if passenger.DocumentType == "" {
+		params.add(field+".documentType", codeRequired, "is required")
+	} else if !slices.Contains(documentTypes, passenger.DocumentType) {
+		params.add(field+".documentType", codeInvalidValue, "must be one of "+strings.Join(documentTypes, ", "))
+	}
+
+	// a passport has to stay valid until the flight departs
+	if passenger.DocumentExpiryDate == "" {
+		if passenger.DocumentType == "passport" {
+			params.add(field+".documentExpiryDate", codeRequired, "is required for passports")
+		}
+	} else if _, err := time.Parse(time.DateOnly, passenger.DocumentExpiryDate); err != nil {
+		params.add(field+".documentExpiryDate", codeInvalidFormat, "must be a date in YYYY-MM-DD format")
+	} else if passenger.DocumentExpiryDate <= departureDate {
+		params.add(field+".documentExpiryDate", codeBeforeDeparture, "must be after the departure date "+departureDate)
+	}
+}
+
+func validateAndNormalizeContact(params *invalidParams, contact *models.ContactDetails) {
+	contact.Email = strings.ToLower(strings.TrimSpace(contact.Email))
+	contact.Phone = strings.ReplaceAll(strings.TrimSpace(contact.Phone), " ", "")
+
+	if contact.Email == "" {
+		params.add("contact.email", codeRequired, "is required")
+	} else if address, err := mail.ParseAddress(contact.Email); err != nil || address.Address != contact.Email {
+		params.add("contact.email", codeInvalidFormat, "must be a valid email address")
+	}
+
+	validateRequiredPattern(params, "contact.phone", contact.Phone, phonePattern, "must be an international number such as +6281234567890")
+}
+
+func validateRequiredPattern(params *invalidParams, field string, value string, pattern *regexp.Regexp, reason string) {
+	if value == "" {
+		params.add(field, codeRequired, "is required")
+	} else if !pattern.MatchString(value) {
+		params.add(field, codeInvalidFormat, reason)
+	}
+}
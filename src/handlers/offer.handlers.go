@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"bookcabin-app-go/src/models"
+	"bookcabin-app-go/src/services"
+	"bookcabin-app-go/src/utils"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RevalidateOffer(ctx *gin.Context) {
+	var req models.RevalidateRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondBindingProblem(ctx, err)
+		return
+	}
+
+	offer, params := parseOfferToken("offerToken", req.OfferToken)
+	locale := validateLocale(&params, req.Locale, ctx.GetHeader("Accept-Language"))
+
+	if len(params) > 0 {
+		respondValidationProblem(ctx, params)
+		return
+	}
+
+	offerService := services.NewOfferService()
+	res, err := offerService.Revalidate(ctx, ctx.Param("flightId"), offer, locale, isInternalRequest(ctx))
+
+	switch {
+	case errors.Is(err, services.ErrOfferMismatch):
+		respondValidationProblem(ctx, invalidParams{{Name: "offerToken", Code: codeInvalidValue, Reason: err.Error()}})
+	case errors.Is(err, services.ErrOfferNotFound):
+		respondNotFoundProblem(ctx, "flight "+offer.FlightID+" is no longer offered by "+offer.Provider)
+	case err != nil:
+		respondInternalProblem(ctx, err)
+	default:
+		ctx.JSON(http.StatusOK, res)
+	}
+}
+
+func GetFareRules(ctx *gin.Context) {
+	offer, locale, params := parseOfferTokenQuery(ctx)
+	if len(params) > 0 {
+		respondValidationProblem(ctx, params)
+		return
+	}
+
+	offerService := services.NewOfferService()
+	res, err := offerService.GetFareRules(ctx, ctx.Param("flightId"), offer, locale)
+
+	switch {
+	case errors.Is(err, services.ErrOfferMismatch):
+		respondValidationProblem(ctx, invalidParams{{Name: "offerToken", Code: codeInvalidValue, Reason: err.Error()}})
+	case err != nil:
+		respondInternalProblem(ctx, err)
+	default:
+		ctx.JSON(http.StatusOK, res)
+	}
+}
+
+func GetAncillaries(ctx *gin.Context) {
+	offer, locale, params := parseOfferTokenQuery(ctx)
+	if len(params) > 0 {
+		respondValidationProblem(ctx, params)
+		return
+	}
+
+	offerService := services.NewOfferService()
+	res, err := offerService.GetAncillaries(ctx, ctx.Param("flightId"), offer, locale)
+
+	switch {
+	case errors.Is(err, services.ErrOfferMismatch):
+		respondValidationProblem(ctx, invalidParams{{Name: "offerToken", Code: codeInvalidValue, Reason: err.Error()}})
+	case errors.Is(err, services.ErrOfferNotFound):
+		respondNotFoundProblem(ctx, "flight "+offer.FlightID+" is no longer offered by "+offer.Provider)
+	case err != nil:
+		respondInternalProblem(ctx, err)
+	default:
+		ctx.JSON(http.StatusOK, res)
+	}
+}
+
+// Offer token and locale from the query string of GET endpoints
+func parseOfferTokenQuery(ctx *gin.Context) (models.Offer, string, invalidParams) {
+	var params invalidParams
+	var offer models.Offer
+
+	if token := ctx.Query("offerToken"); token == "" {
+		params.add("offerToken", codeRequired, "is required")
+	} else {
+		offer, params = parseOfferToken("offerToken", token)
+	}
+	locale := validateLocale(&params, ctx.Query("locale"), ctx.GetHeader("Accept-Language"))
+
+	return offer, locale, params
+}
+
+// Verify an offer token sent by the client, reporting a bad or expired token as an invalid param
+func parseOfferToken(field string, token string) (models.Offer, invalidParams) {
+	var params invalidParams
+
+	offer, err := utils.ParseOfferToken(token)
+	switch {
+	case errors.Is(err, utils.ErrExpiredOfferToken):
+		params.add(field, codeExpired, "has expired, search or revalidate again")
+	case err != nil:
+		params.add(field, codeInvalidValue, err.Error())
+	}
+
+	return offer, params
+}
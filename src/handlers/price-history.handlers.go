@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"bookcabin-app-go/src/constants"
+	"bookcabin-app-go/src/models"
+	"bookcabin-app-go/src/services"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPriceHistoryDays = 30
+	maxPriceHistoryDays     = 365
+)
+
+func GetPriceHistory(ctx *gin.Context) {
+	var params invalidParams
+
+	origin := strings.ToUpper(ctx.Param("origin"))
+	destination := strings.ToUpper(ctx.Param("destination"))
+
+	query := models.PriceHistoryQuery{
+		Origins:       validateLocation(&params, "origin", &origin),
+		Destinations:  validateLocation(&params, "destination", &destination),
+		DepartureDate: ctx.Query("departureDate"),
+		FlightID:      ctx.Query("flightId"),
+		Currency:      strings.ToUpper(ctx.DefaultQuery("currency", constants.DefaultCurrency)),
+		Days:          defaultPriceHistoryDays,
+	}
+
+	if query.DepartureDate != "" {
+		if _, err := time.Parse(time.DateOnly, query.DepartureDate); err != nil {
+			params.add("departureDate", codeInvalidFormat, "must be a date in YYYY-MM-DD format")
+		}
+	}
+
+	if days := ctx.Query("days"); days != "" {
+		var err error
+		query.Days, err = strconv.Atoi(days)
+		if err != nil || query.Days < 1 || query.Days > maxPriceHistoryDays {
+			params.add("days", codeOutOfRange, fmt.Sprintf("must be between 1 and %d", maxPriceHistoryDays))
+		}
+	}
+
+	if _, ok := constants.CurrencyDecimals[query.Currency]; !ok {
+		params.add("currency", codeInvalidValue, "must be one of "+strings.Join(slices.Sorted(maps.Keys(constants.CurrencyDecimals)), ", "))
+	}
+
+	locale := validateLocale(&params, ctx.Query("locale"), ctx.GetHeader("Accept-Language"))
+
+	if len(params) > 0 {
+		respondValidationProblem(ctx, params)
+		return
+	}
+
+	priceHistoryService := services.NewPriceHistoryService()
+	res, err := priceHistoryService.GetHistory(ctx, origin, destination, query, locale)
+
+	if err != nil {
+		respondInternalProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"bookcabin-app-go/src/models"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	problemContentType = "application/problem+json"
+
+	problemTypeValidation    = "/problems/validation-error"
+	problemTypeMalformedBody = "/problems/malformed-body"
+	problemTypeInternalError = "/problems/internal-error"
+	problemTypeNotFound      = "/problems/not-found"
+	problemTypeForbidden     = "/problems/forbidden"
+
+	problemTypeOfferUnavailable = "/problems/offer-unavailable"
+	problemTypePriceChanged     = "/problems/price-changed"
+	problemTypePromoUnavailable = "/problems/promo-unavailable"
+	problemTypeNotCancellable   = "/problems/booking-not-cancellable"
+	problemTypeOfferHeld        = "/problems/offer-already-held"
+	problemTypeHoldsUnsupported = "/problems/seat-holds-unsupported"
+)
+
+/* Machine-readable codes for invalid request fields */
+const (
+	codeRequired          = "required"
+	codeInvalidType       = "invalid_type"
+	codeInvalidFormat     = "invalid_format"
+	codeInvalidValue      = "invalid_value"
+	codeUnknownAirport    = "unknown_airport"
+	codeSameAsOrigin      = "same_as_origin"
+	codeDateInPast        = "date_in_past"
+	codeBeforeDeparture   = "before_departure"
+	codeOutOfRange        = "out_of_range"
+	codeMinGreaterThanMax = "min_greater_than_max"
+	codeExpired           = "expired"
+)
+
+type invalidParams []models.InvalidParam
+
+func (p *invalidParams) add(name string, code string, reason string) {
+	*p = append(*p, models.InvalidParam{Name: name, Code: code, Reason: reason})
+}
+
+func respondProblem(ctx *gin.Context, problem models.ProblemDetails) {
+	problem.Instance = ctx.Request.URL.Path
+	if problem.Title == "" {
+		problem.Title = http.StatusText(problem.Status)
+	}
+
+	// gin keeps a Content-Type that is already set when rendering JSON
+	ctx.Header("Content-Type", problemContentType)
+	ctx.JSON(problem.Status, problem)
+}
+
+func respondValidationProblem(ctx *gin.Context, params invalidParams) {
+	respondProblem(ctx, models.ProblemDetails{
+		Type:          problemTypeValidation,
+		Title:         "Your request parameters didn't validate",
+		Status:        http.StatusBadRequest,
+		InvalidParams: params,
+	})
+}
+
+func respondNotFoundProblem(ctx *gin.Context, detail string) {
+	respondProblem(ctx, models.ProblemDetails{
+		Type:   problemTypeNotFound,
+		Status: http.StatusNotFound,
+		Detail: detail,
+	})
+}
+
+func respondForbiddenProblem(ctx *gin.Context, detail string) {
+	respondProblem(ctx, models.ProblemDetails{
+		Type:   problemTypeForbidden,
+		Status: http.StatusForbidden,
+		Detail: detail,
+	})
+}
+
+func respondConflictProblem(ctx *gin.Context, problemType string, title string, detail string) {
+	respondProblem(ctx, models.ProblemDetails{
+		Type:   problemType,
+		Title:  title,
+		Status: http.StatusConflict,
+		Detail: detail,
+	})
+}
+
+// Log the error and keep its details, which may name Redis or file internals,
+// out of the response
+func respondInternalProblem(ctx *gin.Context, err error) {
+	log.Printf("%s %s: %v", ctx.Request.Method, ctx.Request.URL.Path, err)
+
+	respondProblem(ctx, models.ProblemDetails{
+		Type:   problemTypeInternalError,
+		Status: http.StatusInternalServerError,
+		Detail: "The request could not be completed, please try again later",
+	})
+}
+
+// Turn a ShouldBindJSON error into a problem, listing the fields when the body
+// parsed but failed the binding rules
+func respondBindingProblem(ctx *gin.Context, err error) {
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		var params invalidParams
+		for _, fieldError := range validationErrors {
+			params.add(jsonFieldName(fieldError.Field()), codeRequired, "is required")
+		}
+		respondValidationProblem(ctx, params)
+		return
+	}
+
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) {
+		var params invalidParams
+		params.add(typeError.Field, codeInvalidType, "must be a "+typeError.Type.String())
+		respondValidationProblem(ctx, params)
+		return
+	}
+
+	respondProblem(ctx, models.ProblemDetails{
+		Type:   problemTypeMalformedBody,
+		Title:  "Request body is not valid JSON",
+		Status: http.StatusBadRequest,
+		Detail: err.Error(),
+	})
+}
+
+func jsonFieldName(field string) string {
+	if field == "" {
+		return field
+	}
+	return strings.ToLower(field[:1]) + field[1:]
+}
@@ -1,26 +1,49 @@
 package handlers
 
 import (
+	"bookcabin-app-go/src/constants"
+	"bookcabin-app-go/src/libs"
 	"bookcabin-app-go/src/models"
+	"bookcabin-app-go/src/references"
 	"bookcabin-app-go/src/services"
-	"errors"
+	"bookcabin-app-go/src/utils"
+	"cmp"
+	"crypto/subtle"
+	"fmt"
+	"maps"
 	"net/http"
+	"regexp"
+	"slices"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+const (
+	maxNearbyRadiusKm = 500
+	maxPassengers     = 9
+)
+
+var (
+	locationCodePattern = regexp.MustCompile(`^[A-Z]{3,4}$`)
+	cabinClasses        = []string{"economy", "premium_economy", "business", "first"}
 )
 
 func SearchFlights(ctx *gin.Context) {
 	var req models.SearchRequest
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondBindingProblem(ctx, err)
 		return
 	}
 
-	if err := validateAndNormalizeSearchRequest(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	req.Internal = isInternalRequest(ctx)
+
+	if params := validateAndNormalizeSearchRequest(&req, ctx.GetHeader("Accept-Language")); len(params) > 0 {
+		respondValidationProblem(ctx, params)
 		return
 	}
 
@@ -28,28 +51,33 @@ func SearchFlights(ctx *gin.Context) {
 	res, err := searchService.Search(ctx, req)
 
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondInternalProblem(ctx, err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, res)
 }
 
-func validateAndNormalizeSearchRequest(req *models.SearchRequest) error {
-	if err := validateDate(req.DepartureDate); err != nil {
-		return errors.New("invalid departureDate")
-	}
+// Validate every field and collect all problems, so clients can highlight each invalid input at once
+func validateAndNormalizeSearchRequest(req *models.SearchRequest, acceptLanguage string) invalidParams {
+	var params invalidParams
 
-	if req.ReturnDate != nil {
-		if err := validateDate(*req.ReturnDate); err != nil {
-			return errors.New("invalid returnDate")
-		}
+	originAirports := validateLocation(&params, "origin", &req.Origin)
+	destinationAirports := validateLocation(&params, "destination", &req.Destination)
+
+	if slices.ContainsFunc(destinationAirports, func(airport string) bool {
+		return slices.Contains(originAirports, airport)
+	}) {
+		params.add("destination", codeSameAsOrigin, "must be different from origin")
 	}
 
-	// sort Filters.Airlines for flight search caching purpose
-	sort.Strings(req.Filters.Airlines)
+	validateTravelDates(&params, req, originAirports)
+
+	if req.Passengers < 0 || req.Passengers > maxPassengers {
+		params.add("passengers", codeOutOfRange, fmt.Sprintf("must be between 1 and %d", maxPassengers))
+	}
 
-	if req.Passengers <= 0 {
+	if req.Passengers == 0 {
 		req.Passengers = 1
 	}
 
@@ -57,18 +85,246 @@ func validateAndNormalizeSearchRequest(req *models.SearchRequest) error {
 		req.CabinClass = "economy"
 	}
 
-	if req.SortBy == "" {
-		req.SortBy = "best_value"
+	if !slices.Contains(cabinClasses, req.CabinClass) {
+		params.add("cabinClass", codeInvalidValue, "must be one of "+strings.Join(cabinClasses, ", "))
+	}
+
+	if req.Currency == "" {
+		req.Currency = constants.DefaultCurrency
+	}
+
+	if _, ok := constants.CurrencyDecimals[req.Currency]; !ok {
+		params.add("currency", codeInvalidValue, "must be one of "+strings.Join(slices.Sorted(maps.Keys(constants.CurrencyDecimals)), ", "))
+	}
+
+	req.Locale = validateLocale(&params, req.Locale, acceptLanguage)
+
+	if req.Channel == "" {
+		req.Channel = constants.DefaultChannel
+	}
+
+	if !slices.Contains(constants.SalesChannels, req.Channel) {
+		params.add("channel", codeInvalidValue, "must be one of "+strings.Join(constants.SalesChannels, ", "))
+	}
+
+	req.PromoCode = strings.ToUpper(strings.TrimSpace(req.PromoCode))
+
+	if req.OriginRadiusKm < 0 || req.OriginRadiusKm > maxNearbyRadiusKm {
+		params.add("originRadiusKm", codeOutOfRange, fmt.Sprintf("must be between 0 and %d", maxNearbyRadiusKm))
+	}
+
+	if req.DestinationRadiusKm < 0 || req.DestinationRadiusKm > maxNearbyRadiusKm {
+		params.add("destinationRadiusKm", codeOutOfRange, fmt.Sprintf("must be between 0 and %d", maxNearbyRadiusKm))
+	}
+
+	validateScoring(&params, req)
+	validateAndNormalizeFilters(&params, &req.Filters)
+	validateAndNormalizeSortKeys(&params, req)
+
+	return params
+}
+
+// Pick the best supported locale from Accept-Language, the first supported locale is the fallback
+func getLocaleFromHeader(acceptLanguage string) string {
+	tags := make([]language.Tag, 0, len(constants.SupportedLocales))
+	for _, locale := range constants.SupportedLocales {
+		tags = append(tags, language.MustParse(locale))
+	}
+
+	_, index := language.MatchStrings(language.NewMatcher(tags), acceptLanguage)
+	return constants.SupportedLocales[index]
+}
+
+// Locale from the request, else from Accept-Language. Region subtags like en-US
+// are accepted, only the language decides the formatting.
+func validateLocale(params *invalidParams, locale string, acceptLanguage string) string {
+	if locale == "" {
+		return getLocaleFromHeader(acceptLanguage)
+	}
+
+	if tag, err := language.Parse(locale); err == nil {
+		base, _ := tag.Base()
+		locale = base.String()
+	}
+
+	if !slices.Contains(constants.SupportedLocales, locale) {
+		params.add("locale", codeInvalidValue, "must be one of "+strings.Join(constants.SupportedLocales, ", "))
+	}
+	return locale
+}
+
+// Internal callers send the INTERNAL_API_KEY in the X-Internal-Api-Key header
+func isInternalRequest(ctx *gin.Context) bool {
+	apiKey := libs.GetEnv("INTERNAL_API_KEY", "")
+	if apiKey == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(ctx.GetHeader("X-Internal-Api-Key")), []byte(apiKey)) == 1
+}
+
+// Check each code of a location against the airport reference data, returning
+// the airports it covers. ICAO airport codes are normalized to IATA.
+func validateLocation(params *invalidParams, field string, location *string) []string {
+	codes := strings.Split(*location, ",")
+	var airports []string
+
+	for i, code := range codes {
+		code = toIATAAirportCode(strings.TrimSpace(code))
+		codes[i] = code
+
+		if !locationCodePattern.MatchString(code) {
+			params.add(field, codeInvalidFormat, "must be uppercase 3-letter IATA airport or city codes or 4-letter ICAO airport codes, got "+code)
+			continue
+		}
+
+		expanded, err := references.ExpandLocation(code)
+		if err != nil {
+			params.add(field, codeUnknownAirport, err.Error())
+			continue
+		}
+		airports = append(airports, expanded...)
+	}
+
+	*location = strings.Join(codes, ",")
+	return airports
+}
+
+func validateTravelDates(params *invalidParams, req *models.SearchRequest, originAirports []string) {
+	departureDate, departureErr := time.Parse(time.DateOnly, req.DepartureDate)
+	if departureErr != nil {
+		params.add("departureDate", codeInvalidFormat, "must be a date in YYYY-MM-DD format")
+	} else if !allowPastDates() && req.DepartureDate < getToday(originAirports) {
+		params.add("departureDate", codeDateInPast, "must not be in the past")
+	}
+
+	if req.ReturnDate == nil {
+		return
+	}
+
+	returnDate, returnErr := time.Parse(time.DateOnly, *req.ReturnDate)
+	if returnErr != nil {
+		params.add("returnDate", codeInvalidFormat, "must be a date in YYYY-MM-DD format")
+	} else if departureErr == nil && returnDate.Before(departureDate) {
+		params.add("returnDate", codeBeforeDeparture, "must be on or after departureDate")
+	}
+}
+
+// Today's date at the origin, since a departure is only in the past where it departs from
+func getToday(originAirports []string) string {
+	now := time.Now()
+	if len(originAirports) > 0 {
+		if tz := references.GetAirportLocation(originAirports[0]); tz != nil {
+			now = now.In(tz)
+		}
+	}
+	return now.Format(time.DateOnly)
+}
+
+func allowPastDates() bool {
+	return libs.GetEnv("SEARCH_ALLOW_PAST_DATES", "false") == "true"
+}
+
+func validateScoring(params *invalidParams, req *models.SearchRequest) {
+	if req.ScoringWeights != nil {
+		weights := *req.ScoringWeights
+		if weights.Price < 0 ||
+			weights.Duration < 0 ||
+			weights.Stops < 0 ||
+			weights.Amenities < 0 ||
+			weights.CheckedBaggage < 0 ||
+			weights.Total() <= 0 {
+			params.add("scoringWeights", codeOutOfRange, "weights must not be negative and at least one must be positive")
+		}
+		req.ScoringProfile = ""
+		return
 	}
 
-	if req.SortOrder == "" {
-		req.SortOrder = "asc"
+	if req.ScoringProfile == "" {
+		req.ScoringProfile = utils.DefaultScoringProfile
 	}
 
-	return nil
+	if _, ok := utils.GetScoringProfiles()[req.ScoringProfile]; !ok {
+		params.add("scoringProfile", codeInvalidValue, "unknown scoring profile "+req.ScoringProfile)
+	}
 }
 
-func validateDate(date string) error {
-	_, err := time.Parse(time.DateOnly, date)
-	return err
+func validateAndNormalizeFilters(params *invalidParams, filters *models.Filters) {
+	nonNegatives := map[string]float64{
+		"filters.priceMin":               filters.PriceMin,
+		"filters.priceMax":               filters.PriceMax,
+		"filters.maxStops":               float64(filters.MaxStops),
+		"filters.maxDurationMinutes":     float64(filters.MaxDurationMinutes),
+		"filters.maxLayoverMinutes":      float64(filters.MaxLayoverMinutes),
+		"filters.maxTotalLayoverMinutes": float64(filters.MaxTotalLayoverMinutes),
+		"filters.minConnectionMinutes":   float64(filters.MinConnectionMinutes),
+	}
+
+	for _, field := range slices.Sorted(maps.Keys(nonNegatives)) {
+		if nonNegatives[field] < 0 {
+			params.add(field, codeOutOfRange, "must not be negative")
+		}
+	}
+
+	if filters.PriceMax > 0 && filters.PriceMin > filters.PriceMax {
+		params.add("filters.priceMin", codeMinGreaterThanMax, "must not be greater than filters.priceMax")
+	}
+
+	// sort Filters.Airlines for flight search caching purpose
+	sort.Strings(filters.Airlines)
+
+	filters.ExcludedConnectionAirports = normalizeAirportCodes(params, "filters.excludedConnectionAirports", filters.ExcludedConnectionAirports)
+	filters.RequiredConnectionAirports = normalizeAirportCodes(params, "filters.requiredConnectionAirports", filters.RequiredConnectionAirports)
+}
+
+// Give every sort key an explicit direction: its own, then sortOrder, then the key's default
+func validateAndNormalizeSortKeys(params *invalidParams, req *models.SearchRequest) {
+	if req.SortOrder != "" && !isValidSortOrder(req.SortOrder) {
+		params.add("sortOrder", codeInvalidValue, "must be asc or desc")
+	}
+
+	if len(req.SortBy) == 0 {
+		req.SortBy = models.SortKeys{{Key: utils.SortByBestValue}}
+	}
+
+	for i, sortKey := range req.SortBy {
+		defaultOrder, ok := utils.DefaultSortOrders[sortKey.Key]
+		if !ok {
+			params.add("sortBy", codeInvalidValue, "unknown sort key "+sortKey.Key)
+			continue
+		}
+
+		if sortKey.Order != "" && !isValidSortOrder(sortKey.Order) {
+			params.add("sortBy", codeInvalidValue, "direction of "+sortKey.Key+" must be asc or desc")
+			continue
+		}
+
+		if sortKey.Order == "" {
+			req.SortBy[i].Order = cmp.Or(req.SortOrder, defaultOrder)
+		}
+	}
+}
+
+func isValidSortOrder(order string) bool {
+	return order == "asc" || order == "desc"
+}
+
+// Providers report airports by IATA code, so ICAO codes are translated
+func toIATAAirportCode(code string) string {
+	if airport, ok := references.GetAirport(code); ok && code == airport.ICAO && airport.IATA != "" {
+		return airport.IATA
+	}
+	return code
+}
+
+func normalizeAirportCodes(params *invalidParams, field string, codes []string) []string {
+	for i, code := range codes {
+		codes[i] = toIATAAirportCode(strings.ToUpper(strings.TrimSpace(code)))
+
+		if _, ok := references.GetAirport(codes[i]); !ok || !locationCodePattern.MatchString(codes[i]) {
+			params.add(fmt.Sprintf("%s[%d]", field, i), codeUnknownAirport, "unknown airport "+codes[i])
+		}
+	}
+	sort.Strings(codes)
+	return codes
 }
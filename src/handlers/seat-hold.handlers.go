@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"bookcabin-app-go/src/models"
+	"bookcabin-app-go/src/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func HoldSeats(ctx *gin.Context) {
+	var req models.SeatHoldRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondBindingProblem(ctx, err)
+		return
+	}
+
+	offer, params := parseOfferToken("offerToken", req.OfferToken)
+	if len(params) > 0 {
+		respondValidationProblem(ctx, params)
+		return
+	}
+
+	seatHoldService := services.NewSeatHoldService()
+	hold, err := seatHoldService.Hold(ctx, offer, getLocaleFromHeader(ctx.GetHeader("Accept-Language")))
+
+	switch {
+	case errors.Is(err, services.ErrOfferNotFound):
+		respondNotFoundProblem(ctx, "flight "+offer.FlightID+" is no longer offered by "+offer.Provider)
+	case errors.Is(err, services.ErrOfferAlreadyHeld):
+		respondConflictProblem(ctx, problemTypeOfferHeld, "Offer is already held", err.Error())
+	case errors.Is(err, services.ErrSeatHoldsDisabled):
+		respondConflictProblem(ctx, problemTypeHoldsUnsupported, "Seats cannot be held for this offer", err.Error())
+	case errors.Is(err, services.ErrOfferUnavailable):
+		respondConflictProblem(ctx, problemTypeOfferUnavailable, "Offer is no longer available", err.Error())
+	case errors.Is(err, services.ErrPriceChanged):
+		respondConflictProblem(ctx, problemTypePriceChanged, "Offer price has changed", err.Error())
+	case err != nil:
+		respondInternalProblem(ctx, err)
+	default:
+		ctx.JSON(http.StatusCreated, hold)
+	}
+}
+
+func GetSeatHold(ctx *gin.Context) {
+	seatHoldService := services.NewSeatHoldService()
+	hold, err := seatHoldService.Get(ctx, ctx.Param("id"))
+
+	switch {
+	case errors.Is(err, services.ErrSeatHoldNotFound):
+		respondNotFoundProblem(ctx, "seat hold "+ctx.Param("id")+" does not exist or has expired")
+	case err != nil:
+		respondInternalProblem(ctx, err)
+	default:
+		ctx.JSON(http.StatusOK, hold)
+	}
+}
+
+func ReleaseSeatHold(ctx *gin.Context) {
+	seatHoldService := services.NewSeatHoldService()
+	_, err := seatHoldService.Release(ctx, ctx.Param("id"))
+
+	switch {
+	case errors.Is(err, services.ErrSeatHoldNotFound):
+		respondNotFoundProblem(ctx, "seat hold "+ctx.Param("id")+" does not exist or has been released")
+	case err != nil:
+		respondInternalProblem(ctx, err)
+	default:
+		ctx.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,17 @@
+package libs
+
+import (
+	"os"
+)
+
+const (
+	configBasePath = "src/configs/"
+)
+
+func ReadConfigFile(file string) ([]byte, error) {
+	return os.ReadFile(configBasePath + file)
+}
+
+func StatConfigFile(file string) (os.FileInfo, error) {
+	return os.Stat(configBasePath + file)
+}
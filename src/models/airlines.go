@@ -0,0 +1,11 @@
+package models
+
+// AirlineReference is an entry of the airline registry
+type AirlineReference struct {
+	IATA        string   `json:"iata"`
+	ICAO        string   `json:"icao"`
+	Name        string   `json:"name"`
+	Aliases     []string `json:"aliases"`
+	Alliance    *string  `json:"alliance"`
+	CountryCode string   `json:"country_code"`
+}
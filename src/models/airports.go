@@ -0,0 +1,36 @@
+package models
+
+type Airport struct {
+	IATA string `json:"iata"`
+	ICAO string `json:"icao"`
+	Name string `json:"name"`
+	City string `json:"city"`
+	// city name per locale when it differs from City, e.g. {"id": "Singapura"}
+	CityNames   map[string]string `json:"city_names,omitempty"`
+	Region      string            `json:"region"`
+	Country     string            `json:"country"`
+	CountryCode string            `json:"country_code"`
+	Timezone    string            `json:"timezone"`
+	Latitude    float64           `json:"latitude"`
+	Longitude   float64           `json:"longitude"`
+}
+
+type AirportMatch struct {
+	Airport
+	Score     float64 `json:"score"`
+	MatchedOn string  `json:"matched_on"`
+}
+
+type AirportSearchResponse struct {
+	Query   string         `json:"query"`
+	Country string         `json:"country"`
+	Results []AirportMatch `json:"results"`
+}
+
+// MetropolitanArea groups the airports serving one city under an IATA city code, e.g. JKT = CGK + HLP
+type MetropolitanArea struct {
+	Code        string   `json:"code"`
+	Name        string   `json:"name"`
+	CountryCode string   `json:"country_code"`
+	Airports    []string `json:"airports"`
+}
@@ -0,0 +1,38 @@
+package models
+
+/* Ancillary catalogue config, per airline */
+
+type AncillaryOption struct {
+	Code     string  `json:"code"`
+	Name     string  `json:"name"`
+	WeightKg float64 `json:"weightKg"`
+	Price    float64 `json:"price"`
+}
+
+// AncillaryCatalogue lists an airline's extras with their price per passenger
+// per flight in Currency
+type AncillaryCatalogue struct {
+	Currency string            `json:"currency"`
+	Baggage  []AncillaryOption `json:"baggage"`
+	Seats    []AncillaryOption `json:"seats"`
+	Meals    []AncillaryOption `json:"meals"`
+}
+
+// Ancillary is an extra a passenger can buy on a flight
+type Ancillary struct {
+	Type     string   `json:"type"`
+	Code     string   `json:"code"`
+	Name     string   `json:"name"`
+	WeightKg *float64 `json:"weight_kg,omitempty"`
+	Price    Price    `json:"price"`
+}
+
+// AncillariesResponse lists the extras of an offer, priced per passenger in the offer's currency
+type AncillariesResponse struct {
+	FlightID string      `json:"flight_id"`
+	Provider string      `json:"provider"`
+	Currency string      `json:"currency"`
+	Baggage  []Ancillary `json:"baggage"`
+	Seats    []Ancillary `json:"seats"`
+	Meals    []Ancillary `json:"meals"`
+}
@@ -0,0 +1,95 @@
+package models
+
+type BookingRequest struct {
+	OfferToken string             `json:"offerToken" binding:"required"`
+	Passengers []PassengerDetails `json:"passengers" binding:"required"`
+	Contact    ContactDetails     `json:"contact"`
+	Locale     string             `json:"locale"`
+}
+
+type PassengerDetails struct {
+	Title              string `json:"title"`
+	FirstName          string `json:"firstName"`
+	LastName           string `json:"lastName"`
+	DateOfBirth        string `json:"dateOfBirth"`
+	Nationality        string `json:"nationality"`
+	DocumentType       string `json:"documentType"`
+	DocumentNumber     string `json:"documentNumber"`
+	DocumentExpiryDate string `json:"documentExpiryDate"`
+}
+
+type ContactDetails struct {
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+}
+
+// ProviderBookingRequest is what a booking provider needs to reserve seats
+type ProviderBookingRequest struct {
+	Flight     Flight
+	Passengers []PassengerDetails
+	Contact    ContactDetails
+
+	// the provider's hold on the seats, when the offer was held
+	HoldReference string
+}
+
+type Booking struct {
+	ID         string             `json:"id"`
+	PNR        string             `json:"pnr"`
+	Status     string             `json:"status"`
+	Provider   string             `json:"provider"`
+	Flight     Flight             `json:"flight"`
+	Passengers []BookingPassenger `json:"passengers"`
+	Contact    BookingContact     `json:"contact"`
+	Fare       Price              `json:"fare"`
+	TotalPrice Price              `json:"total_price"`
+	PromoCode  string             `json:"promo_code,omitempty"`
+	Locale     string             `json:"locale"`
+	Refund     *BookingRefund     `json:"refund,omitempty"`
+	History    []BookingEvent     `json:"history"`
+	CreatedAt  string             `json:"created_at"`
+	UpdatedAt  string             `json:"updated_at"`
+
+	// returned once when the booking is created, only its hash is stored
+	AccessToken     string `json:"access_token,omitempty"`
+	AccessTokenHash string `json:"access_token_hash,omitempty"`
+}
+
+// BookingEvent is one entry of a booking's audit trail
+type BookingEvent struct {
+	Status string `json:"status"`
+	Note   string `json:"note"`
+	At     string `json:"at"`
+}
+
+type BookingRefund struct {
+	Refundable      bool  `json:"refundable"`
+	CancellationFee Price `json:"cancellation_fee"`
+	Amount          Price `json:"amount"`
+}
+
+type CancelBookingRequest struct {
+	Reason string `json:"reason"`
+}
+
+type BookingSearchResponse struct {
+	Email   string    `json:"email"`
+	Results []Booking `json:"results"`
+}
+
+type BookingPassenger struct {
+	Title              string `json:"title"`
+	FirstName          string `json:"first_name"`
+	LastName           string `json:"last_name"`
+	DateOfBirth        string `json:"date_of_birth"`
+	Nationality        string `json:"nationality"`
+	DocumentType       string `json:"document_type"`
+	DocumentNumber     string `json:"document_number"`
+	DocumentExpiryDate string `json:"document_expiry_date,omitempty"`
+	TicketNumber       string `json:"ticket_number,omitempty"`
+}
+
+type BookingContact struct {
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"fmt"
+)
+
+// FxRates holds how many units of each currency one unit of Base buys
+type FxRates struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// Cross rate to convert an amount from one currency into another
+func (r FxRates) Rate(from string, to string) (float64, error) {
+	fromRate, okFrom := r.Rates[from]
+	toRate, okTo := r.Rates[to]
+
+	if from == r.Base {
+		fromRate, okFrom = 1, true
+	}
+	if to == r.Base {
+		toRate, okTo = 1, true
+	}
+
+	if !okFrom || !okTo || fromRate <= 0 {
+		return 0, fmt.Errorf("no exchange rate from %s to %s", from, to)
+	}
+
+	return toRate / fromRate, nil
+}
+
+// OriginalPrice is the provider's price before currency conversion
+type OriginalPrice struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+	Rate     float64 `json:"rate"`
+}
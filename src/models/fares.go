@@ -0,0 +1,68 @@
+package models
+
+/* Fare rules config, per airline and fare type */
+
+// FareFee is a percentage of the fare plus a fixed amount per passenger in Currency
+type FareFee struct {
+	Percent  float64 `json:"percent"`
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+type NoShowRule struct {
+	Refundable bool    `json:"refundable"`
+	Fee        FareFee `json:"fee"`
+}
+
+type FareBaggageRule struct {
+	CarryOn string `json:"carryOn"`
+	Checked string `json:"checked"`
+}
+
+// FareRule is what a fare allows: refunds and changes, each for a fee, what
+// happens on a no-show, and the baggage included
+type FareRule struct {
+	Refundable      bool            `json:"refundable"`
+	CancellationFee FareFee         `json:"cancellationFee"`
+	Changeable      bool            `json:"changeable"`
+	ChangeFee       FareFee         `json:"changeFee"`
+	NoShow          NoShowRule      `json:"noShow"`
+	Baggage         FareBaggageRule `json:"baggage"`
+}
+
+// AirlineFareRules is an airline's rule, overridden for the fare types it sells
+type AirlineFareRules struct {
+	FareRule
+	FareTypes map[string]FareRule `json:"fareTypes"`
+}
+
+type FareRulesConfig struct {
+	Default  FareRule                    `json:"default"`
+	Airlines map[string]AirlineFareRules `json:"airlines"`
+}
+
+/* Fare rules of an offer, fees worked out per passenger */
+
+type FareRulesResponse struct {
+	FlightID     string `json:"flight_id"`
+	Provider     string `json:"provider"`
+	FlightNumber string `json:"flight_number"`
+	Airline      string `json:"airline"`
+	FareType     string `json:"fare_type,omitempty"`
+	Fare         Price  `json:"fare"`
+
+	// which rule applies: "fare_type", "airline" or "default"
+	Source string `json:"source"`
+
+	Refundable      bool       `json:"refundable"`
+	CancellationFee *Price     `json:"cancellation_fee"`
+	Changeable      bool       `json:"changeable"`
+	ChangeFee       *Price     `json:"change_fee"`
+	NoShow          NoShowFees `json:"no_show"`
+	Baggage         Baggage    `json:"baggage"`
+}
+
+type NoShowFees struct {
+	Refundable bool   `json:"refundable"`
+	Fee        *Price `json:"fee"`
+}
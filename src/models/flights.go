@@ -1,59 +1,185 @@
 package models
 
 type SearchRequest struct {
-	Origin        string  `json:"origin" binding:"required"`
-	Destination   string  `json:"destination" binding:"required"`
-	DepartureDate string  `json:"departureDate" binding:"required"`
-	ReturnDate    *string `json:"returnDate"`
-	Passengers    int     `json:"passengers"`
-	CabinClass    string  `json:"cabinClass"`
-	Filters       Filters `json:"filters"`
-	SortBy        string  `json:"sortBy"`
-	SortOrder     string  `json:"sortOrder"`
+	Origin        string   `json:"origin" binding:"required"`
+	Destination   string   `json:"destination" binding:"required"`
+	DepartureDate string   `json:"departureDate" binding:"required"`
+	ReturnDate    *string  `json:"returnDate"`
+	Passengers    int      `json:"passengers"`
+	CabinClass    string   `json:"cabinClass"`
+	Currency      string   `json:"currency"`
+	Locale        string   `json:"locale"`
+	Channel       string   `json:"channel"`
+	PromoCode     string   `json:"promoCode"`
+	Filters       Filters  `json:"filters"`
+	SortBy        SortKeys `json:"sortBy"`
+	SortOrder     string   `json:"sortOrder"`
+
+	ScoringProfile string          `json:"scoringProfile"`
+	ScoringWeights *ScoringWeights `json:"scoringWeights"`
+	IncludeScore   bool            `json:"includeScore"`
+	AllInPrice     bool            `json:"allInPrice"`
+
+	OriginRadiusKm      float64 `json:"originRadiusKm"`
+	DestinationRadiusKm float64 `json:"destinationRadiusKm"`
+
+	// airports covered by Origin and Destination, expanded by the search service
+	OriginAirports      []string `json:"-"`
+	DestinationAirports []string `json:"-"`
+
+	// nearby airports added by the radius options, with their distance in km
+	OriginAlternatives      map[string]float64 `json:"-"`
+	DestinationAlternatives map[string]float64 `json:"-"`
+
+	// set for callers with a valid internal API key, exposes pricing breakdowns
+	Internal bool `json:"-"`
+
+	// skip the cached provider responses, e.g. when revalidating an offer
+	BypassCache bool `json:"-"`
 }
 
 type Filters struct {
-	PriceMin           int      `json:"priceMin"`
-	PriceMax           int      `json:"priceMax"`
+	PriceMin           float64  `json:"priceMin"`
+	PriceMax           float64  `json:"priceMax"`
 	MaxStops           int      `json:"maxStops"`
 	Airlines           []string `json:"airlines"`
 	DepartureTimeRange string   `json:"departureTimeRange"`
 	ArrivalTimeRange   string   `json:"arrivalTimeRange"`
 	MaxDurationMinutes int      `json:"maxDurationMinutes"`
+
+	MaxLayoverMinutes          int      `json:"maxLayoverMinutes"`
+	MaxTotalLayoverMinutes     int      `json:"maxTotalLayoverMinutes"`
+	MinConnectionMinutes       int      `json:"minConnectionMinutes"`
+	ExcludeOvernightLayovers   bool     `json:"excludeOvernightLayovers"`
+	ExcludedConnectionAirports []string `json:"excludedConnectionAirports"`
+	RequiredConnectionAirports []string `json:"requiredConnectionAirports"`
+
+	ParetoOptimalOnly bool `json:"paretoOptimalOnly"`
+	RefundableOnly    bool `json:"refundableOnly"`
+
+	CheckedBagIncluded bool `json:"checkedBagIncluded"`
 }
 
+// Baggage allowances per passenger, nil when none is included or the provider
+// doesn't say. Raw is the provider's own description.
 type Baggage struct {
-	CarryOn *string `json:"carry_on"`
-	Checked *string `json:"checked"`
+	CarryOn         *BaggageAllowance `json:"carry_on"`
+	Checked         *BaggageAllowance `json:"checked"`
+	CheckedIncluded bool              `json:"checked_included"`
+	Raw             string            `json:"raw,omitempty"`
+}
+
+// BaggageAllowance is a number of pieces, a total weight, or both
+type BaggageAllowance struct {
+	Pieces   *int     `json:"pieces"`
+	WeightKg *float64 `json:"weight_kg"`
 }
 
 type Flight struct {
-	ID             string     `json:"id"`
-	Provider       string     `json:"provider"`
-	Airline        Airline    `json:"airline"`
-	FlightNumber   string     `json:"flight_number"`
-	Departure      EventPoint `json:"departure"`
-	Arrival        EventPoint `json:"arrival"`
-	Duration       Duration   `json:"duration"`
-	Stops          int        `json:"stops"`
-	Price          Price      `json:"price"`
-	AvailableSeats int        `json:"available_seats"`
-	CabinClass     string     `json:"cabin_class"`
-	Aircraft       *string    `json:"aircraft"`
-	Amenities      *[]string  `json:"amenities"`
-	Baggage        Baggage    `json:"baggage"`
+	ID           string            `json:"id"`
+	Provider     string            `json:"provider"`
+	Airline      Airline           `json:"airline"`
+	FlightNumber string            `json:"flight_number"`
+	Departure    EventPoint        `json:"departure"`
+	Arrival      EventPoint        `json:"arrival"`
+	Duration     Duration          `json:"duration"`
+	Stops        int               `json:"stops"`
+	Price        Price             `json:"price"`
+	Pricing      *PricingBreakdown `json:"pricing,omitempty"`
+
+	// set when another airline flies the flight sold under this flight number
+	OperatingCarrier *OperatingCarrier `json:"operating_carrier,omitempty"`
+
+	// set when the requested promo code applies to the flight
+	DiscountedPrice *Price    `json:"discounted_price,omitempty"`
+	Discount        *Discount `json:"discount,omitempty"`
+
+	// price with a 20 kg checked bag, set when allInPrice is requested
+	AllInPrice *Price `json:"all_in_price,omitempty"`
+
+	AvailableSeats int       `json:"available_seats"`
+	CabinClass     string    `json:"cabin_class"`
+	FareType       string    `json:"fare_type,omitempty"`
+	Refundable     bool      `json:"refundable"`
+	Aircraft       *string   `json:"aircraft"`
+	Amenities      *[]string `json:"amenities"`
+	Baggage        Baggage   `json:"baggage"`
+	Segments       []Segment `json:"segments"`
+	Layovers       []Layover `json:"layovers"`
+
+	Score          *float64        `json:"score,omitempty"`
+	ScoreBreakdown *ScoreBreakdown `json:"score_breakdown,omitempty"`
+
+	Labels        []string `json:"labels,omitempty"`
+	ParetoOptimal bool     `json:"pareto_optimal"`
+
+	AlternativeOffers []AlternativeOffer `json:"alternative_offers,omitempty"`
+
+	RouteMatch *RouteMatch `json:"route_match"`
+
+	// signed reference to this result, sent back to revalidate and book it
+	OfferToken string `json:"offer_token,omitempty"`
+}
+
+// RouteMatch tells which of the requested airports a flight matched
+type RouteMatch struct {
+	RequestedOrigin      string `json:"requested_origin"`
+	MatchedOrigin        string `json:"matched_origin"`
+	RequestedDestination string `json:"requested_destination"`
+	MatchedDestination   string `json:"matched_destination"`
+
+	// distance from the requested airport, set when an alternative airport matched
+	AlternativeAirport    bool     `json:"alternative_airport"`
+	OriginDistanceKm      *float64 `json:"origin_distance_km"`
+	DestinationDistanceKm *float64 `json:"destination_distance_km"`
+}
+
+// AlternativeOffer is the same physical flight sold by another provider
+type AlternativeOffer struct {
+	ID           string            `json:"id"`
+	Provider     string            `json:"provider"`
+	Airline      Airline           `json:"airline"`
+	FlightNumber string            `json:"flight_number"`
+	Price        Price             `json:"price"`
+	Pricing      *PricingBreakdown `json:"pricing,omitempty"`
+}
+
+// Segment is a single leg of a flight. Providers that only report the
+// connecting airports leave the intermediate times and durations empty.
+type Segment struct {
+	FlightNumber     string            `json:"flight_number"`
+	OperatingCarrier *OperatingCarrier `json:"operating_carrier,omitempty"`
+	Departure        EventPoint        `json:"departure"`
+	Arrival          EventPoint        `json:"arrival"`
+	Duration         *Duration         `json:"duration"`
+}
+
+// OperatingCarrier is the airline flying a codeshare, under its own flight number
+type OperatingCarrier struct {
+	Airline      Airline `json:"airline"`
+	FlightNumber string  `json:"flight_number"`
+}
+
+type Layover struct {
+	Airport         string `json:"airport"`
+	DurationMinutes int    `json:"duration_minutes"`
 }
 
 type Airline struct {
-	Name string `json:"name"`
-	Code string `json:"code"`
+	Name     string  `json:"name"`
+	Code     string  `json:"code"`
+	ICAO     string  `json:"icao_code"`
+	Alliance *string `json:"alliance"`
+	LogoURL  string  `json:"logo_url"`
 }
 
 type EventPoint struct {
 	Airport   string `json:"airport"`
 	City      string `json:"city"`
-	DateTime  string `json:"datetime"`
-	Timestamp int64  `json:"timestamp"`
+	DateTime  string `json:"datetime,omitempty"`
+	Timestamp int64  `json:"timestamp,omitempty"`
+
+	DisplayDateTime string `json:"display_datetime,omitempty"`
 }
 
 type Duration struct {
@@ -62,15 +188,26 @@ type Duration struct {
 }
 
 type Price struct {
-	Amount    int    `json:"amount"`
-	Currency  string `json:"currency"`
-	Formatted string `json:"formatted"`
+	Amount    float64        `json:"amount"`
+	Currency  string         `json:"currency"`
+	Formatted string         `json:"formatted"`
+	Original  *OriginalPrice `json:"original,omitempty"`
 }
 
 type SearchResponse struct {
-	Criteria SearchRequest `json:"search_criteria"`
-	Metadata Metadata      `json:"metadata"`
-	Flights  []Flight      `json:"flights"`
+	Criteria   SearchRequest `json:"search_criteria"`
+	Metadata   Metadata      `json:"metadata"`
+	Highlights Highlights    `json:"highlights"`
+	Flights    []Flight      `json:"flights"`
+}
+
+// Highlights point to the flight IDs worth a badge in the results
+type Highlights struct {
+	Cheapest        *string  `json:"cheapest"`
+	Fastest         *string  `json:"fastest"`
+	EarliestArrival *string  `json:"earliest_arrival"`
+	BestValue       *string  `json:"best_value"`
+	ParetoOptimal   []string `json:"pareto_optimal"`
 }
 
 type Metadata struct {
@@ -78,6 +215,9 @@ type Metadata struct {
 	ProvidersQueried int  `json:"providers_queried"`
 	ProvidersSuccess int  `json:"providers_succeeded"`
 	ProvidersFailed  int  `json:"providers_failed"`
+	DuplicatesMerged int  `json:"duplicates_merged"`
 	SearchTimeMs     int  `json:"search_time_ms"`
 	CacheHit         bool `json:"cache_hit"`
+
+	Promo *PromoStatus `json:"promo,omitempty"`
 }
@@ -0,0 +1,28 @@
+package models
+
+type SeatHoldRequest struct {
+	OfferToken string `json:"offerToken" binding:"required"`
+}
+
+// SeatHold keeps seats on a flight aside for a while, e.g. during payment
+type SeatHold struct {
+	ID                 string `json:"id"`
+	Provider           string `json:"provider"`
+	ProviderReference  string `json:"provider_reference"`
+	FlightID           string `json:"flight_id"`
+	FlightNumber       string `json:"flight_number"`
+	DepartureTimestamp int64  `json:"departure_timestamp"`
+	Passengers         int    `json:"passengers"`
+	CreatedAt          string `json:"created_at"`
+	ExpiresAt          string `json:"expires_at"`
+
+	// fresh token for the held offer, to book it
+	OfferToken string `json:"offer_token,omitempty"`
+}
+
+// SeatHoldEvent is published whenever a hold is created or released
+type SeatHoldEvent struct {
+	Type string   `json:"type"`
+	Hold SeatHold `json:"hold"`
+	At   string   `json:"at"`
+}
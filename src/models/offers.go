@@ -0,0 +1,59 @@
+package models
+
+// RevalidateRequest carries the offer token of the search result a client wants to check out
+type RevalidateRequest struct {
+	OfferToken string `json:"offerToken" binding:"required"`
+	Locale     string `json:"locale"`
+}
+
+// Offer is what an offer token vouches for: the flight, the passengers it was
+// priced for and the fare each of them pays
+type Offer struct {
+	Provider      string  `json:"provider"`
+	FlightID      string  `json:"flight_id"`
+	FlightNumber  string  `json:"flight_number"`
+	Airline       string  `json:"airline"`
+	FareType      string  `json:"fare_type,omitempty"`
+	Origin        string  `json:"origin"`
+	Destination   string  `json:"destination"`
+	DepartureDate string  `json:"departure_date"`
+	Passengers    int     `json:"passengers"`
+	CabinClass    string  `json:"cabin_class"`
+	Channel       string  `json:"channel"`
+	Fare          float64 `json:"fare"`
+	Currency      string  `json:"currency"`
+	PromoCode     string  `json:"promo_code,omitempty"`
+	HoldID        string  `json:"hold_id,omitempty"`
+	ExpiresAt     int64   `json:"exp"`
+}
+
+// Search request for the same route, date and passengers as the offer
+func (o Offer) SearchRequest() SearchRequest {
+	return SearchRequest{
+		Origin:        o.Origin,
+		Destination:   o.Destination,
+		DepartureDate: o.DepartureDate,
+		Passengers:    o.Passengers,
+		CabinClass:    o.CabinClass,
+		Currency:      o.Currency,
+		Channel:       o.Channel,
+		PromoCode:     o.PromoCode,
+	}
+}
+
+type RevalidateResponse struct {
+	FlightID        string  `json:"flight_id"`
+	Provider        string  `json:"provider"`
+	Available       bool    `json:"available"`
+	AvailableSeats  int     `json:"available_seats"`
+	Passengers      int     `json:"passengers"`
+	PriceStatus     string  `json:"price_status"`
+	PreviousPrice   Price   `json:"previous_price"`
+	CurrentPrice    Price   `json:"current_price"`
+	PriceDifference float64 `json:"price_difference"`
+	Flight          Flight  `json:"flight"`
+
+	// only issued when the offer can be booked
+	OfferToken string `json:"offer_token,omitempty"`
+	ExpiresAt  string `json:"expires_at,omitempty"`
+}
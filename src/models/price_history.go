@@ -0,0 +1,48 @@
+package models
+
+// PriceObservation is a flight's selling price seen by a search
+type PriceObservation struct {
+	Origin        string  `json:"origin"`
+	Destination   string  `json:"destination"`
+	DepartureDate string  `json:"departure_date"`
+	FlightID      string  `json:"flight_id"`
+	Provider      string  `json:"provider"`
+	Amount        float64 `json:"amount"`
+	Currency      string  `json:"currency"`
+	ObservedAt    int64   `json:"observed_at"`
+}
+
+// PriceHistoryQuery selects the observations of a route, optionally for a
+// departure date or flight, seen within the last Days days
+type PriceHistoryQuery struct {
+	Origins       []string
+	Destinations  []string
+	DepartureDate string
+	FlightID      string
+	Currency      string
+	Days          int
+}
+
+// PriceHistoryDay sums up the prices observed on one day
+type PriceHistoryDay struct {
+	Date         string  `json:"date"`
+	Min          float64 `json:"min"`
+	Avg          float64 `json:"avg"`
+	Max          float64 `json:"max"`
+	Observations int     `json:"observations"`
+}
+
+type PriceHistoryResponse struct {
+	Origin        string `json:"origin"`
+	Destination   string `json:"destination"`
+	DepartureDate string `json:"departure_date,omitempty"`
+	FlightID      string `json:"flight_id,omitempty"`
+	Currency      string `json:"currency"`
+
+	// "low", "typical" or "high", comparing the latest day with the ones before,
+	// empty while there is too little history
+	PriceLevel   string `json:"price_level,omitempty"`
+	CurrentPrice *Price `json:"current_price"`
+
+	Days []PriceHistoryDay `json:"days"`
+}
@@ -0,0 +1,34 @@
+package models
+
+// PricingRule is a markup or commission applied on top of provider fares.
+// Empty conditions match everything, so a rule without conditions is a default.
+type PricingRule struct {
+	Name               string   `json:"name"`
+	Type               string   `json:"type"`
+	Airlines           []string `json:"airlines"`
+	Routes             []string `json:"routes"`
+	CabinClasses       []string `json:"cabinClasses"`
+	Providers          []string `json:"providers"`
+	Channels           []string `json:"channels"`
+	MinDaysToDeparture *int     `json:"minDaysToDeparture"`
+	MaxDaysToDeparture *int     `json:"maxDaysToDeparture"`
+	Percent            float64  `json:"percent"`
+	FixedAmount        float64  `json:"fixedAmount"`
+	Currency           string   `json:"currency"`
+}
+
+type PricingRules struct {
+	Rules []PricingRule `json:"rules"`
+}
+
+// PricingBreakdown shows how the selling price was built from the provider fare,
+// only exposed to internal callers
+type PricingBreakdown struct {
+	ProviderFare float64  `json:"provider_fare"`
+	Commission   float64  `json:"commission"`
+	NetFare      float64  `json:"net_fare"`
+	Markup       float64  `json:"markup"`
+	SellingPrice float64  `json:"selling_price"`
+	Currency     string   `json:"currency"`
+	AppliedRules []string `json:"applied_rules"`
+}
@@ -0,0 +1,48 @@
+package models
+
+// Promotion is a promo code with its discount and eligibility rules.
+// Amounts are in Currency, empty conditions match every flight.
+type Promotion struct {
+	Code        string  `json:"code"`
+	Description string  `json:"description"`
+	Percent     float64 `json:"percent"`
+	FixedAmount float64 `json:"fixedAmount"`
+	MaxDiscount float64 `json:"maxDiscount"`
+	MinSpend    float64 `json:"minSpend"`
+	Currency    string  `json:"currency"`
+
+	Airlines []string `json:"airlines"`
+	Routes   []string `json:"routes"`
+
+	// departure dates the promo covers, YYYY-MM-DD
+	TravelDateFrom string `json:"travelDateFrom"`
+	TravelDateTo   string `json:"travelDateTo"`
+
+	// period the code can be used in, RFC 3339
+	ValidFrom  string `json:"validFrom"`
+	ValidUntil string `json:"validUntil"`
+
+	// total redemptions allowed, 0 means unlimited
+	UsageLimit int64 `json:"usageLimit"`
+}
+
+type Promotions struct {
+	Promotions []Promotion `json:"promotions"`
+}
+
+// Discount is what a promo code takes off one passenger's price
+type Discount struct {
+	Code        string  `json:"code"`
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount"`
+	Currency    string  `json:"currency"`
+	Formatted   string  `json:"formatted"`
+}
+
+// PromoStatus tells whether the requested promo code could be applied
+type PromoStatus struct {
+	Code            string `json:"code"`
+	Status          string `json:"status"`
+	Message         string `json:"message"`
+	EligibleFlights int    `json:"eligible_flights"`
+}
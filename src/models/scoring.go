@@ -0,0 +1,28 @@
+package models
+
+// ScoringWeights sets how much each factor counts towards the best value
+// score. Weights are relative to each other, they don't need to add up to 1.
+type ScoringWeights struct {
+	Price          float64 `json:"price"`
+	Duration       float64 `json:"duration"`
+	Stops          float64 `json:"stops"`
+	Amenities      float64 `json:"amenities"`
+	CheckedBaggage float64 `json:"checkedBaggage"`
+}
+
+func (w ScoringWeights) Total() float64 {
+	return w.Price + w.Duration + w.Stops + w.Amenities + w.CheckedBaggage
+}
+
+// ScoreBreakdown holds each factor's weighted contribution to the final score
+type ScoreBreakdown struct {
+	Price          float64 `json:"price"`
+	Duration       float64 `json:"duration"`
+	Stops          float64 `json:"stops"`
+	Amenities      float64 `json:"amenities"`
+	CheckedBaggage float64 `json:"checked_baggage"`
+}
+
+func (b ScoreBreakdown) Total() float64 {
+	return b.Price + b.Duration + b.Stops + b.Amenities + b.CheckedBaggage
+}
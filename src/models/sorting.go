@@ -0,0 +1,57 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+type SortKey struct {
+	Key   string
+	Order string
+}
+
+// SortKeys is an ordered list of sort keys, each with an optional direction.
+// It accepts "price", "price:asc,departure:asc" or ["price:asc", "departure"].
+type SortKeys []SortKey
+
+func (k *SortKeys) UnmarshalJSON(data []byte) error {
+	var rawKeys []string
+
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		rawKeys = strings.Split(single, ",")
+	} else if err := json.Unmarshal(data, &rawKeys); err != nil {
+		return errors.New("sortBy must be a string or a list of strings")
+	}
+
+	keys := SortKeys{}
+	for _, rawKey := range rawKeys {
+		key, order, _ := strings.Cut(strings.TrimSpace(rawKey), ":")
+		if key == "" {
+			continue
+		}
+
+		keys = append(keys, SortKey{
+			Key:   strings.ToLower(strings.TrimSpace(key)),
+			Order: strings.ToLower(strings.TrimSpace(order)),
+		})
+	}
+
+	*k = keys
+	return nil
+}
+
+func (k SortKeys) MarshalJSON() ([]byte, error) {
+	rawKeys := make([]string, 0, len(k))
+
+	for _, key := range k {
+		if key.Order == "" {
+			rawKeys = append(rawKeys, key.Key)
+			continue
+		}
+		rawKeys = append(rawKeys, key.Key+":"+key.Order)
+	}
+
+	return json.Marshal(rawKeys)
+}
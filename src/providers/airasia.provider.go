@@ -2,13 +2,15 @@ package providers
 
 import (
 	"bookcabin-app-go/src/models"
+	"bookcabin-app-go/src/references"
 	"bookcabin-app-go/src/utils"
+	"cmp"
 	"encoding/json"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/samber/lo"
 )
 
 type AirAsiaRawFlight struct {
@@ -37,22 +39,30 @@ type AirAsiaRawFlightResponse struct {
 
 type AirAsiaProvider struct {
 	props SearchProviderProperty
+	*StubBookingProvider
 }
 
 func NewAirAsiaProvider() *AirAsiaProvider {
-	return &AirAsiaProvider{props: SearchProviderProperty{
-		Name:         "AirAsia",
-		SuccessRate:  90,
-		ResponseTime: [2]int{50, 150},
-		MockFile:     "airasia_search_response.json",
-	}}
+	return &AirAsiaProvider{
+		props: SearchProviderProperty{
+			Name:         "AirAsia",
+			SuccessRate:  90,
+			ResponseTime: [2]int{50, 150},
+			MockFile:     "airasia_search_response.json",
+		},
+		StubBookingProvider: NewStubBookingProvider("AirAsia"),
+	}
+}
+
+func (pvd *AirAsiaProvider) Name() string {
+	return pvd.props.Name
 }
 
 func (pvd *AirAsiaProvider) Fetch(
 	ctx *gin.Context,
 	req models.SearchRequest,
 ) ([]models.Flight, error) {
-	data, err := SimulateFetchWithWait(ctx.Request.Context(), pvd.props)
+	data, err := SimulateFetchWithWait(ctx.Request.Context(), pvd.props, req.BypassCache)
 	if err != nil {
 		return nil, err
 	}
@@ -66,22 +76,27 @@ func (pvd *AirAsiaProvider) Fetch(
 
 	for _, flight := range rawFlightResponse.Flights {
 
-		if flight.FromAirport != req.Origin ||
-			flight.ToAirport != req.Destination ||
+		if !isRequestedRoute(req, flight.FromAirport, flight.ToAirport) ||
 			flight.DepartTime.Format(time.DateOnly) != req.DepartureDate ||
 			flight.Seats < req.Passengers {
 			continue
 		}
 
 		durationInt := int(flight.DurationHours * 60)
+		airline := references.NormalizeAirline(getAirAsiaAirlineCode(flight.FlightCode), flight.Airline)
 
-		results = append(results, models.Flight{
-			ID:       utils.GetFlightId(flight.Airline, flight.FlightCode),
-			Provider: pvd.props.Name,
-			Airline: models.Airline{
-				Name: flight.Airline,
-				Code: getAirAsiaAirlineCode(flight.FlightCode),
-			},
+		layovers := make([]models.Layover, 0, len(flight.Stops))
+		for _, stop := range flight.Stops {
+			layovers = append(layovers, models.Layover{
+				Airport:         stop.Airport,
+				DurationMinutes: stop.WaitTimeMinutes,
+			})
+		}
+
+		normalized := models.Flight{
+			ID:           utils.GetFlightId(airline.Name, flight.FlightCode),
+			Provider:     pvd.props.Name,
+			Airline:      airline,
 			FlightNumber: flight.FlightCode,
 			Departure: models.EventPoint{
 				Airport:   flight.FromAirport,
@@ -101,21 +116,58 @@ func (pvd *AirAsiaProvider) Fetch(
 			},
 			Stops: len(flight.Stops),
 			Price: models.Price{
-				Amount:    flight.PriceIdr,
+				Amount:    float64(flight.PriceIdr),
 				Currency:  "IDR",
-				Formatted: utils.FormatPrice(flight.PriceIdr, "IDR"),
+				Formatted: utils.FormatPrice(float64(flight.PriceIdr), "IDR"),
 			},
 			AvailableSeats: flight.Seats,
 			CabinClass:     strings.ToLower(flight.CabinClass),
 			Aircraft:       nil,
 			Amenities:      nil,
-			Baggage:        parseBaggageInfo(flight.BaggageNote),
-		})
+			Baggage:        parseAirAsiaBaggage(flight.BaggageNote),
+			Layovers:       layovers,
+		}
+		normalized.Segments = buildSegmentsFromLayovers(normalized)
+
+		results = append(results, normalized)
 	}
 
-	return results, err
+	return pvd.ApplyInventory(results, req.Passengers), err
 }
 
+// The designator in front of the flight number, IATA ("QZ520") or ICAO ("AWQ520")
+var airAsiaFlightCodePattern = regexp.MustCompile(`^([A-Z0-9]{2,3}?)\s*\d{1,4}[A-Z]?$`)
+
+// AirAsia only sends the group's brand name, so the airline operating under it
+// is resolved from the flight code's designator through the registry
 func getAirAsiaAirlineCode(flightCode string) string {
-	return lo.Substring(flightCode, 0, 2)
+	match := airAsiaFlightCodePattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(flightCode)))
+	if match == nil {
+		return ""
+	}
+
+	if airline, ok := references.GetAirline(match[1]); ok {
+		return airline.IATA
+	}
+	return match[1]
+}
+
+// AirAsia describes baggage in words, e.g. "Cabin baggage only, checked bags
+// additional fee". Cabin baggage without a weight is a single bag, and checked
+// bags that cost extra are not included.
+func parseAirAsiaBaggage(baggageNote string) models.Baggage {
+	var carryOn, checked *models.BaggageAllowance
+
+	for _, part := range strings.Split(baggageNote, ",") {
+		part = strings.ToLower(part)
+
+		switch {
+		case strings.Contains(part, "cabin"):
+			carryOn = cmp.Or(utils.ParseBaggageAllowance(part), utils.NewBaggagePieces(1))
+		case strings.Contains(part, "checked") && !strings.Contains(part, "additional fee"):
+			checked = utils.ParseBaggageAllowance(part)
+		}
+	}
+
+	return utils.NewBaggage(carryOn, checked, baggageNote)
 }
@@ -0,0 +1,90 @@
+package providers
+
+import (
+	"bookcabin-app-go/src/libs"
+	"bookcabin-app-go/src/models"
+	"bookcabin-app-go/src/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+const (
+	AncillaryTypeBaggage = "baggage"
+	AncillaryTypeSeat    = "seat"
+	AncillaryTypeMeal    = "meal"
+
+	defaultAncillaryCatalogue = "default"
+)
+
+// AncillaryProvider is implemented by providers selling extras on their flights
+type AncillaryProvider interface {
+	Name() string
+	// extras for the offered flight, priced per passenger in the provider's currency
+	GetAncillaries(ctx context.Context, offer models.Offer) ([]models.Ancillary, error)
+}
+
+// Catalogues are loaded once from config, a file that can't be read or parsed is an error
+var loadAncillaryCatalogues = sync.OnceValues(func() (map[string]models.AncillaryCatalogue, error) {
+	var catalogues map[string]models.AncillaryCatalogue
+	file := libs.GetEnv("ANCILLARIES_FILE", "ancillaries.json")
+
+	data, err := libs.ReadConfigFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("ancillary catalogues: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &catalogues); err != nil {
+		return nil, fmt.Errorf("ancillary catalogues %s: %w", file, err)
+	}
+	return catalogues, nil
+})
+
+// Checked at startup, so a missing or malformed catalogue stops the app
+// instead of selling no extras
+func CheckAncillaryCatalogues() error {
+	_, err := loadAncillaryCatalogues()
+	return err
+}
+
+// The stub sells what src/configs/ancillaries.json lists for the airline, or the default catalogue
+func (pvd *StubBookingProvider) GetAncillaries(ctx context.Context, offer models.Offer) ([]models.Ancillary, error) {
+	ancillaryCatalogues, err := loadAncillaryCatalogues()
+	if err != nil {
+		return nil, err
+	}
+
+	catalogue, ok := ancillaryCatalogues[offer.Airline]
+	if !ok {
+		catalogue = ancillaryCatalogues[defaultAncillaryCatalogue]
+	}
+
+	var ancillaries []models.Ancillary
+	for _, option := range catalogue.Baggage {
+		ancillary := newAncillary(AncillaryTypeBaggage, option, catalogue.Currency)
+		ancillary.WeightKg = &option.WeightKg
+		ancillaries = append(ancillaries, ancillary)
+	}
+	for _, option := range catalogue.Seats {
+		ancillaries = append(ancillaries, newAncillary(AncillaryTypeSeat, option, catalogue.Currency))
+	}
+	for _, option := range catalogue.Meals {
+		ancillaries = append(ancillaries, newAncillary(AncillaryTypeMeal, option, catalogue.Currency))
+	}
+
+	return ancillaries, nil
+}
+
+func newAncillary(ancillaryType string, option models.AncillaryOption, currency string) models.Ancillary {
+	return models.Ancillary{
+		Type: ancillaryType,
+		Code: option.Code,
+		Name: option.Name,
+		Price: models.Price{
+			Amount:    option.Price,
+			Currency:  currency,
+			Formatted: utils.FormatPrice(option.Price, currency),
+		},
+	}
+}
@@ -3,8 +3,10 @@ package providers
 import (
 	"bookcabin-app-go/src/constants"
 	"bookcabin-app-go/src/models"
+	"bookcabin-app-go/src/references"
 	"bookcabin-app-go/src/utils"
 	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -45,22 +47,30 @@ type BatikAirRawFlightResponse struct {
 
 type BatikAirProvider struct {
 	props SearchProviderProperty
+	*StubBookingProvider
 }
 
 func NewBatikAirProvider() *BatikAirProvider {
-	return &BatikAirProvider{props: SearchProviderProperty{
-		Name:         "BatikAir",
-		SuccessRate:  100,
-		ResponseTime: [2]int{200, 400},
-		MockFile:     "batik_air_search_response.json",
-	}}
+	return &BatikAirProvider{
+		props: SearchProviderProperty{
+			Name:         "BatikAir",
+			SuccessRate:  100,
+			ResponseTime: [2]int{200, 400},
+			MockFile:     "batik_air_search_response.json",
+		},
+		StubBookingProvider: NewStubBookingProvider("BatikAir"),
+	}
+}
+
+func (pvd *BatikAirProvider) Name() string {
+	return pvd.props.Name
 }
 
 func (pvd *BatikAirProvider) Fetch(
 	ctx *gin.Context,
 	req models.SearchRequest,
 ) ([]models.Flight, error) {
-	data, err := SimulateFetchWithWait(ctx.Request.Context(), pvd.props)
+	data, err := SimulateFetchWithWait(ctx.Request.Context(), pvd.props, req.BypassCache)
 	if err != nil {
 		return nil, err
 	}
@@ -79,20 +89,26 @@ func (pvd *BatikAirProvider) Fetch(
 
 		if errDept != nil ||
 			errArrv != nil ||
-			flight.Origin != req.Origin ||
-			flight.Destination != req.Destination ||
+			!isRequestedRoute(req, flight.Origin, flight.Destination) ||
 			flightDepartureDate.Format(time.DateOnly) != req.DepartureDate ||
 			flight.SeatsAvailable < req.Passengers {
 			continue
 		}
 
-		results = append(results, models.Flight{
-			ID:       utils.GetFlightId(flight.AirlineName, flight.FlightNumber),
-			Provider: pvd.props.Name,
-			Airline: models.Airline{
-				Name: flight.AirlineName,
-				Code: flight.AirlineIATA,
-			},
+		layovers := make([]models.Layover, 0, len(flight.Connections))
+		for _, connection := range flight.Connections {
+			layovers = append(layovers, models.Layover{
+				Airport:         connection.StopAirport,
+				DurationMinutes: utils.FormatDurationToMinutes(connection.StopDuration),
+			})
+		}
+
+		airline := references.NormalizeAirline(flight.AirlineIATA, flight.AirlineName)
+
+		normalized := models.Flight{
+			ID:           utils.GetFlightId(airline.Name, flight.FlightNumber),
+			Provider:     pvd.props.Name,
+			Airline:      airline,
 			FlightNumber: flight.FlightNumber,
 			Departure: models.EventPoint{
 				Airport:   flight.Origin,
@@ -112,17 +128,39 @@ func (pvd *BatikAirProvider) Fetch(
 			},
 			Stops: flight.NumberOfStops,
 			Price: models.Price{
-				Amount:    flight.Fare.TotalPrice,
+				Amount:    float64(flight.Fare.TotalPrice),
 				Currency:  flight.Fare.CurrencyCode,
-				Formatted: utils.FormatPrice(flight.Fare.TotalPrice, flight.Fare.CurrencyCode),
+				Formatted: utils.FormatPrice(float64(flight.Fare.TotalPrice), flight.Fare.CurrencyCode),
 			},
 			AvailableSeats: flight.SeatsAvailable,
 			CabinClass:     "economy",
 			Aircraft:       &flight.AircraftModel,
 			Amenities:      &flight.OnboardServices,
-			Baggage:        parseBaggageInfo(flight.BaggageInfo),
-		})
+			Baggage:        parseBatikAirBaggage(flight.BaggageInfo),
+			Layovers:       layovers,
+		}
+		normalized.Segments = buildSegmentsFromLayovers(normalized)
+
+		results = append(results, normalized)
+	}
+
+	return pvd.ApplyInventory(results, req.Passengers), err
+}
+
+// Batik Air lists each allowance with its weight, e.g. "7kg cabin, 20kg checked"
+func parseBatikAirBaggage(baggageInfo string) models.Baggage {
+	var carryOn, checked *models.BaggageAllowance
+
+	for _, part := range strings.Split(baggageInfo, ",") {
+		part = strings.ToLower(part)
+
+		switch {
+		case strings.Contains(part, "cabin"):
+			carryOn = utils.ParseBaggageAllowance(part)
+		case strings.Contains(part, "checked"):
+			checked = utils.ParseBaggageAllowance(part)
+		}
 	}
 
-	return results, err
+	return utils.NewBaggage(carryOn, checked, baggageInfo)
 }
@@ -0,0 +1,186 @@
+package providers
+
+import (
+	"bookcabin-app-go/src/models"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+)
+
+var ErrNotEnoughSeats = errors.New("not enough seats left on this flight")
+
+type BookingProvider interface {
+	Name() string
+	// reserve seats and return the airline's PNR
+	Book(ctx context.Context, req models.ProviderBookingRequest) (string, error)
+	// issue one ticket number per passenger for a confirmed PNR
+	IssueTickets(ctx context.Context, pnr string, passengers int) ([]string, error)
+	// cancel a PNR, giving its seats back
+	Cancel(ctx context.Context, pnr string) error
+}
+
+/* Stub booking, keeping seat inventory in memory for each provider */
+type StubBookingProvider struct {
+	inventory *stubInventory
+}
+
+type stubInventory struct {
+	mu    sync.Mutex
+	seats map[string]int // remaining seats by flight and departure
+	pnrs  map[string]stubReservation
+	holds map[string]stubReservation
+}
+
+type stubReservation struct {
+	key   string
+	seats int
+}
+
+var (
+	stubInventories   = make(map[string]*stubInventory)
+	stubInventoriesMu sync.Mutex
+)
+
+// Providers with the same name share one inventory, so seats stay sold across requests
+func NewStubBookingProvider(name string) *StubBookingProvider {
+	stubInventoriesMu.Lock()
+	defer stubInventoriesMu.Unlock()
+
+	inventory, ok := stubInventories[name]
+	if !ok {
+		inventory = &stubInventory{seats: make(map[string]int), pnrs: make(map[string]stubReservation), holds: make(map[string]stubReservation)}
+		stubInventories[name] = inventory
+	}
+
+	return &StubBookingProvider{inventory: inventory}
+}
+
+func getInventoryKey(flight models.Flight) string {
+	return fmt.Sprintf("%s|%d", flight.ID, flight.Departure.Timestamp)
+}
+
+// Replace the seats reported by the provider feed with what is left in the
+// inventory, dropping flights without enough seats for the passengers
+func (pvd *StubBookingProvider) ApplyInventory(flights []models.Flight, passengers int) []models.Flight {
+	pvd.inventory.mu.Lock()
+	defer pvd.inventory.mu.Unlock()
+
+	results := make([]models.Flight, 0, len(flights))
+	for _, flight := range flights {
+		key := getInventoryKey(flight)
+
+		seats, ok := pvd.inventory.seats[key]
+		if !ok {
+			seats = flight.AvailableSeats
+			pvd.inventory.seats[key] = seats
+		}
+		flight.AvailableSeats = seats
+
+		if seats >= passengers {
+			results = append(results, flight)
+		}
+	}
+
+	return results
+}
+
+// Seats held by other holds can't be booked, the booking's own hold becomes
+// the booked seats
+func (pvd *StubBookingProvider) Book(ctx context.Context, req models.ProviderBookingRequest) (string, error) {
+	pvd.inventory.mu.Lock()
+	defer pvd.inventory.mu.Unlock()
+
+	key := getInventoryKey(req.Flight)
+	remaining, ok := pvd.inventory.seats[key]
+	if !ok {
+		remaining = req.Flight.AvailableSeats
+	}
+
+	seats := remaining
+	for reference, hold := range pvd.inventory.holds {
+		if hold.key == key && reference != req.HoldReference {
+			seats -= hold.seats
+		}
+	}
+
+	if seats < len(req.Passengers) {
+		return "", ErrNotEnoughSeats
+	}
+
+	pnr, err := generateCode(6)
+	if err != nil {
+		return "", err
+	}
+
+	pvd.inventory.seats[key] = remaining - len(req.Passengers)
+	delete(pvd.inventory.holds, req.HoldReference)
+	pvd.inventory.pnrs[pnr] = stubReservation{key: key, seats: len(req.Passengers)}
+
+	return pnr, nil
+}
+
+func (pvd *StubBookingProvider) IssueTickets(ctx context.Context, pnr string, passengers int) ([]string, error) {
+	pvd.inventory.mu.Lock()
+	defer pvd.inventory.mu.Unlock()
+
+	if _, ok := pvd.inventory.pnrs[pnr]; !ok {
+		return nil, fmt.Errorf("unknown PNR %s", pnr)
+	}
+
+	tickets := make([]string, 0, passengers)
+	for range passengers {
+		number, err := generateDigits(13)
+		if err != nil {
+			return nil, err
+		}
+		tickets = append(tickets, number)
+	}
+
+	return tickets, nil
+}
+
+func (pvd *StubBookingProvider) Cancel(ctx context.Context, pnr string) error {
+	pvd.inventory.mu.Lock()
+	defer pvd.inventory.mu.Unlock()
+
+	// the inventory lives in memory, PNRs from before a restart have no seats to give back
+	reservation, ok := pvd.inventory.pnrs[pnr]
+	if !ok {
+		return nil
+	}
+
+	pvd.inventory.seats[reservation.key] += reservation.seats
+	delete(pvd.inventory.pnrs, pnr)
+
+	return nil
+}
+
+const pnrAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// Random airline-style record locator, skipping look-alike characters
+func generateCode(length int) (string, error) {
+	code := make([]byte, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(pnrAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = pnrAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+func generateDigits(length int) (string, error) {
+	digits := make([]byte, length)
+	for i := range digits {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		digits[i] = byte('0' + n.Int64())
+	}
+	return string(digits), nil
+}
@@ -0,0 +1,118 @@
+package providers
+
+import (
+	"bookcabin-app-go/src/libs"
+	"bookcabin-app-go/src/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type FxRateProvider interface {
+	GetRates(ctx context.Context) (models.FxRates, error)
+}
+
+// Pick the FX rate source from FX_RATE_SOURCE ("file" or "http"), cached in Redis
+func NewFxRateProvider() FxRateProvider {
+	ttlInSeconds, _ := strconv.Atoi(libs.GetEnv("FX_RATES_CACHE_TTL_IN_S", "3600"))
+
+	var source FxRateProvider = &FileFxRateProvider{
+		File: libs.GetEnv("FX_RATES_FILE", "fx_rates.json"),
+	}
+
+	if libs.GetEnv("FX_RATE_SOURCE", "file") == "http" {
+		source = &HttpFxRateProvider{
+			URL:    libs.GetEnv("FX_RATES_API_URL", ""),
+			Client: &http.Client{Timeout: 5 * time.Second},
+		}
+	}
+
+	return &CachedFxRateProvider{
+		Source:   source,
+		CacheKey: "FX:" + libs.GetEnv("FX_RATE_SOURCE", "file"),
+		TTL:      time.Duration(ttlInSeconds) * time.Second,
+	}
+}
+
+/* File based rates, read from src/configs/ */
+type FileFxRateProvider struct {
+	File string
+}
+
+func (pvd *FileFxRateProvider) GetRates(ctx context.Context) (models.FxRates, error) {
+	var rates models.FxRates
+
+	data, err := libs.ReadConfigFile(pvd.File)
+	if err != nil {
+		return rates, err
+	}
+
+	err = json.Unmarshal(data, &rates)
+	return rates, err
+}
+
+/* HTTP rates API answering with {"base": "USD", "rates": {"IDR": 16650, ...}} */
+type HttpFxRateProvider struct {
+	URL    string
+	Client *http.Client
+}
+
+func (pvd *HttpFxRateProvider) GetRates(ctx context.Context) (models.FxRates, error) {
+	var rates models.FxRates
+
+	if pvd.URL == "" {
+		return rates, errors.New("FX_RATES_API_URL is not set")
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, pvd.URL, nil)
+	if err != nil {
+		return rates, err
+	}
+
+	response, err := pvd.Client.Do(request)
+	if err != nil {
+		return rates, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return rates, fmt.Errorf("failed fetching FX rates: %s", response.Status)
+	}
+
+	err = json.NewDecoder(response.Body).Decode(&rates)
+	return rates, err
+}
+
+/* Keeps rates from another source in Redis for a TTL */
+type CachedFxRateProvider struct {
+	Source   FxRateProvider
+	CacheKey string
+	TTL      time.Duration
+}
+
+func (pvd *CachedFxRateProvider) GetRates(ctx context.Context) (models.FxRates, error) {
+	cache := libs.GetCacheClientInstance()
+
+	cachedData, err := cache.Get(ctx, pvd.CacheKey).Result()
+	if err == nil {
+		var rates models.FxRates
+		if err := json.Unmarshal([]byte(cachedData), &rates); err == nil {
+			return rates, nil
+		}
+	}
+
+	rates, err := pvd.Source.GetRates(ctx)
+	if err != nil {
+		return rates, err
+	}
+
+	if data, err := json.Marshal(rates); err == nil {
+		cache.Set(ctx, pvd.CacheKey, data, pvd.TTL)
+	}
+
+	return rates, nil
+}
@@ -3,9 +3,9 @@ package providers
 import (
 	"bookcabin-app-go/src/constants"
 	"bookcabin-app-go/src/models"
+	"bookcabin-app-go/src/references"
 	"bookcabin-app-go/src/utils"
 	"encoding/json"
-	"strconv"
 	"strings"
 	"time"
 
@@ -54,6 +54,13 @@ type GarudaIndonesiaRawFlight struct {
 		} `json:"arrival"`
 		DurationMinutes int `json:"duration_minutes"`
 		LayoverMinutes  int `json:"layover_minutes,omitempty"`
+
+		// codeshare legs flown by another airline
+		OperatingCarrier *struct {
+			Airline      string `json:"airline"`
+			AirlineCode  string `json:"airline_code"`
+			FlightNumber string `json:"flight_number"`
+		} `json:"operating_carrier,omitempty"`
 	} `json:"segments,omitempty"`
 }
 
@@ -64,22 +71,30 @@ type GarudaIndonesiaRawFlightResponse struct {
 
 type GarudaIndonesiaProvider struct {
 	props SearchProviderProperty
+	*StubBookingProvider
 }
 
 func NewGarudaIndonesiaProvider() *GarudaIndonesiaProvider {
-	return &GarudaIndonesiaProvider{props: SearchProviderProperty{
-		Name:         "GarudaIndonesia",
-		SuccessRate:  100,
-		ResponseTime: [2]int{50, 100},
-		MockFile:     "garuda_indonesia_search_response.json",
-	}}
+	return &GarudaIndonesiaProvider{
+		props: SearchProviderProperty{
+			Name:         "GarudaIndonesia",
+			SuccessRate:  100,
+			ResponseTime: [2]int{50, 100},
+			MockFile:     "garuda_indonesia_search_response.json",
+		},
+		StubBookingProvider: NewStubBookingProvider("GarudaIndonesia"),
+	}
+}
+
+func (pvd *GarudaIndonesiaProvider) Name() string {
+	return pvd.props.Name
 }
 
 func (pvd *GarudaIndonesiaProvider) Fetch(
 	ctx *gin.Context,
 	req models.SearchRequest,
 ) ([]models.Flight, error) {
-	data, err := SimulateFetchWithWait(ctx.Request.Context(), pvd.props)
+	data, err := SimulateFetchWithWait(ctx.Request.Context(), pvd.props, req.BypassCache)
 	if err != nil {
 		return nil, err
 	}
@@ -98,33 +113,29 @@ func (pvd *GarudaIndonesiaProvider) Fetch(
 
 		if errDept != nil ||
 			errArrv != nil ||
-			flight.Departure.Airport != req.Origin ||
-			flight.Arrival.Airport != req.Destination ||
+			!isRequestedRoute(req, flight.Departure.Airport, flight.Arrival.Airport) ||
 			flightDepartureDate.Format(time.DateOnly) != req.DepartureDate ||
 			flight.AvailableSeats < req.Passengers {
 			continue
 		}
 
-		carryOn := strconv.Itoa(flight.Baggage.CarryOn)
-		checked := strconv.Itoa(flight.Baggage.Checked)
+		segments, layovers := buildGarudaIndonesiaSegments(flight)
+		airline := references.NormalizeAirline(flight.AirlineCode, flight.Airline)
 
-		results = append(results, models.Flight{
-			ID:       utils.GetFlightId(flight.Airline, flight.FlightID),
-			Provider: pvd.props.Name,
-			Airline: models.Airline{
-				Name: flight.Airline,
-				Code: flight.AirlineCode,
-			},
+		normalized := models.Flight{
+			ID:           utils.GetFlightId(airline.Name, flight.FlightID),
+			Provider:     pvd.props.Name,
+			Airline:      airline,
 			FlightNumber: flight.FlightID,
 			Departure: models.EventPoint{
 				Airport:   flight.Departure.Airport,
-				City:      flight.Departure.City,
+				City:      getCity(flight.Departure.Airport),
 				DateTime:  utils.FormatDateTime(flightDepartureDate, flight.Departure.Airport),
 				Timestamp: flightDepartureDate.Unix(),
 			},
 			Arrival: models.EventPoint{
 				Airport:   flight.Arrival.Airport,
-				City:      flight.Arrival.City,
+				City:      getCity(flight.Arrival.Airport),
 				DateTime:  utils.FormatDateTime(flightArrivalDate, flight.Arrival.Airport),
 				Timestamp: flightArrivalDate.Unix(),
 			},
@@ -134,20 +145,76 @@ func (pvd *GarudaIndonesiaProvider) Fetch(
 			},
 			Stops: flight.Stops,
 			Price: models.Price{
-				Amount:    flight.Price.Amount,
+				Amount:    float64(flight.Price.Amount),
 				Currency:  flight.Price.Currency,
-				Formatted: utils.FormatPrice(flight.Price.Amount, flight.Price.Currency),
+				Formatted: utils.FormatPrice(float64(flight.Price.Amount), flight.Price.Currency),
 			},
 			AvailableSeats: flight.AvailableSeats,
 			CabinClass:     strings.ToLower(flight.FareClass),
 			Aircraft:       &flight.Aircraft,
 			Amenities:      &flight.Amenities,
-			Baggage: models.Baggage{
-				CarryOn: &carryOn,
-				Checked: &checked,
-			},
+			Baggage:        parseGarudaIndonesiaBaggage(flight),
+			Segments:       segments,
+			Layovers:       layovers,
+		}
+
+		// a single codeshare leg means the whole flight is operated by another airline
+		if len(segments) == 1 {
+			normalized.OperatingCarrier = segments[0].OperatingCarrier
+		}
+		if len(normalized.Segments) == 0 {
+			normalized.Segments = buildSegmentsFromLayovers(normalized)
+		}
+
+		results = append(results, normalized)
+	}
+
+	return pvd.ApplyInventory(results, req.Passengers), err
+}
+
+func buildGarudaIndonesiaSegments(flight GarudaIndonesiaRawFlight) ([]models.Segment, []models.Layover) {
+	segments := make([]models.Segment, 0, len(flight.Segments))
+	layovers := make([]models.Layover, 0)
+
+	for i, segment := range flight.Segments {
+		// layover_minutes is the wait before boarding this segment
+		if i > 0 {
+			layovers = append(layovers, models.Layover{
+				Airport:         segment.Departure.Airport,
+				DurationMinutes: segment.LayoverMinutes,
+			})
+		}
+
+		duration := models.Duration{
+			TotalMinutes: segment.DurationMinutes,
+			Formatted:    utils.FormatDurationToHumans(segment.DurationMinutes),
+		}
+
+		var operatingCarrier *models.OperatingCarrier
+		if segment.OperatingCarrier != nil {
+			operatingCarrier = &models.OperatingCarrier{
+				Airline:      references.NormalizeAirline(segment.OperatingCarrier.AirlineCode, segment.OperatingCarrier.Airline),
+				FlightNumber: segment.OperatingCarrier.FlightNumber,
+			}
+		}
+
+		segments = append(segments, models.Segment{
+			FlightNumber:     segment.FlightNumber,
+			OperatingCarrier: operatingCarrier,
+			Departure:        buildEventPoint(segment.Departure.Airport, segment.Departure.Time),
+			Arrival:          buildEventPoint(segment.Arrival.Airport, segment.Arrival.Time),
+			Duration:         &duration,
 		})
 	}
 
-	return results, err
+	return segments, layovers
+}
+
+// Garuda Indonesia counts baggage in pieces, without weights or a description
+func parseGarudaIndonesiaBaggage(flight GarudaIndonesiaRawFlight) models.Baggage {
+	return utils.NewBaggage(
+		utils.NewBaggagePieces(flight.Baggage.CarryOn),
+		utils.NewBaggagePieces(flight.Baggage.Checked),
+		"",
+	)
 }
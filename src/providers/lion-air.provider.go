@@ -3,6 +3,7 @@ package providers
 import (
 	"bookcabin-app-go/src/constants"
 	"bookcabin-app-go/src/models"
+	"bookcabin-app-go/src/references"
 	"bookcabin-app-go/src/utils"
 	"encoding/json"
 	"strings"
@@ -68,22 +69,30 @@ type LionAirRawFlightResponse struct {
 
 type LionAirProvider struct {
 	props SearchProviderProperty
+	*StubBookingProvider
 }
 
 func NewLionAirProvider() *LionAirProvider {
-	return &LionAirProvider{props: SearchProviderProperty{
-		Name:         "LionAir",
-		SuccessRate:  100,
-		ResponseTime: [2]int{50, 100},
-		MockFile:     "garuda_indonesia_search_response.json",
-	}}
+	return &LionAirProvider{
+		props: SearchProviderProperty{
+			Name:         "LionAir",
+			SuccessRate:  100,
+			ResponseTime: [2]int{50, 100},
+			MockFile:     "lion_air_search_response.json",
+		},
+		StubBookingProvider: NewStubBookingProvider("LionAir"),
+	}
+}
+
+func (pvd *LionAirProvider) Name() string {
+	return pvd.props.Name
 }
 
 func (pvd *LionAirProvider) Fetch(
 	ctx *gin.Context,
 	req models.SearchRequest,
 ) ([]models.Flight, error) {
-	data, err := SimulateFetchWithWait(ctx.Request.Context(), pvd.props)
+	data, err := SimulateFetchWithWait(ctx.Request.Context(), pvd.props, req.BypassCache)
 	if err != nil {
 		return nil, err
 	}
@@ -105,32 +114,37 @@ func (pvd *LionAirProvider) Fetch(
 
 		if errDept != nil ||
 			errArrv != nil ||
-			flight.Route.From.Code != req.Origin ||
-			flight.Route.To.Code != req.Destination ||
+			!isRequestedRoute(req, flight.Route.From.Code, flight.Route.To.Code) ||
 			flightDepartureDate.Format(time.DateOnly) != req.DepartureDate ||
 			flight.SeatsLeft < req.Passengers {
 			continue
 		}
 
 		amenities, baggage := buildLionAirAmenities(flight)
+		airline := references.NormalizeAirline(flight.Carrier.Iata, flight.Carrier.Name)
+
+		layovers := make([]models.Layover, 0, len(flight.Layovers))
+		for _, layover := range flight.Layovers {
+			layovers = append(layovers, models.Layover{
+				Airport:         layover.Airport,
+				DurationMinutes: layover.DurationMinutes,
+			})
+		}
 
-		results = append(results, models.Flight{
-			ID:       utils.GetFlightId(flight.Carrier.Name, flight.ID),
-			Provider: pvd.props.Name,
-			Airline: models.Airline{
-				Name: flight.Carrier.Name,
-				Code: flight.Carrier.Iata,
-			},
+		normalized := models.Flight{
+			ID:           utils.GetFlightId(airline.Name, flight.ID),
+			Provider:     pvd.props.Name,
+			Airline:      airline,
 			FlightNumber: flight.ID,
 			Departure: models.EventPoint{
 				Airport:   flight.Route.From.Code,
-				City:      flight.Route.From.City,
+				City:      getCity(flight.Route.From.Code),
 				DateTime:  utils.FormatDateTime(flightDepartureDate, flight.Route.From.Code),
 				Timestamp: flightDepartureDate.Unix(),
 			},
 			Arrival: models.EventPoint{
 				Airport:   flight.Route.To.Code,
-				City:      flight.Route.To.City,
+				City:      getCity(flight.Route.To.Code),
 				DateTime:  utils.FormatDateTime(flightArrivalDate, flight.Route.To.Code),
 				Timestamp: flightArrivalDate.Unix(),
 			},
@@ -140,19 +154,24 @@ func (pvd *LionAirProvider) Fetch(
 			},
 			Stops: flight.StopCount,
 			Price: models.Price{
-				Amount:    flight.Pricing.Total,
+				Amount:    float64(flight.Pricing.Total),
 				Currency:  flight.Pricing.Currency,
-				Formatted: utils.FormatPrice(flight.Pricing.Total, flight.Pricing.Currency),
+				Formatted: utils.FormatPrice(float64(flight.Pricing.Total), flight.Pricing.Currency),
 			},
 			AvailableSeats: flight.SeatsLeft,
 			CabinClass:     strings.ToLower(flight.Pricing.FareType),
+			FareType:       strings.ToUpper(flight.Pricing.FareType),
 			Aircraft:       &flight.PlaneType,
 			Amenities:      &amenities,
 			Baggage:        baggage,
-		})
+			Layovers:       layovers,
+		}
+		normalized.Segments = buildSegmentsFromLayovers(normalized)
+
+		results = append(results, normalized)
 	}
 
-	return results, err
+	return pvd.ApplyInventory(results, req.Passengers), err
 }
 
 func buildLionAirAmenities(flight LionAirRawFlight) ([]string, models.Baggage) {
@@ -164,8 +183,16 @@ func buildLionAirAmenities(flight LionAirRawFlight) ([]string, models.Baggage) {
 		amenities = append(amenities, "Wifi available")
 	}
 
-	return amenities, models.Baggage{
-		CarryOn: &flight.Services.BaggageAllowance.Cabin,
-		Checked: &flight.Services.BaggageAllowance.Hold,
-	}
+	return amenities, parseLionAirBaggage(flight)
+}
+
+// Lion Air sends each allowance as a weight, e.g. "7 kg" cabin and "20 kg" hold
+func parseLionAirBaggage(flight LionAirRawFlight) models.Baggage {
+	allowance := flight.Services.BaggageAllowance
+
+	return utils.NewBaggage(
+		utils.ParseBaggageAllowance(allowance.Cabin),
+		utils.ParseBaggageAllowance(allowance.Hold),
+		"cabin: "+allowance.Cabin+", hold: "+allowance.Hold,
+	)
 }
@@ -0,0 +1,268 @@
+package providers
+
+import (
+	"bookcabin-app-go/src/libs"
+	"bookcabin-app-go/src/models"
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type PriceHistoryStore interface {
+	Record(ctx context.Context, observations []models.PriceObservation) error
+	Query(ctx context.Context, query models.PriceHistoryQuery) ([]models.PriceObservation, error)
+}
+
+// Pick the price history store from PRICE_HISTORY_STORE ("redis" or "file")
+func NewPriceHistoryStore() PriceHistoryStore {
+	retentionInDays, _ := strconv.Atoi(libs.GetEnv("PRICE_HISTORY_RETENTION_IN_DAYS", "90"))
+	retention := time.Duration(retentionInDays) * 24 * time.Hour
+
+	if libs.GetEnv("PRICE_HISTORY_STORE", "redis") == "file" {
+		return getFilePriceHistoryStore(libs.GetEnv("PRICE_HISTORY_FILE", "price_history.jsonl"), retention)
+	}
+
+	return &RedisPriceHistoryStore{Retention: retention}
+}
+
+// Observations seen within the query's days that match its filters
+func matchesPriceHistoryQuery(observation models.PriceObservation, query models.PriceHistoryQuery, since int64) bool {
+	return observation.ObservedAt >= since &&
+		slices.Contains(query.Origins, observation.Origin) &&
+		slices.Contains(query.Destinations, observation.Destination) &&
+		(query.DepartureDate == "" || observation.DepartureDate == query.DepartureDate) &&
+		(query.FlightID == "" || observation.FlightID == query.FlightID) &&
+		(query.Currency == "" || observation.Currency == query.Currency)
+}
+
+func getPriceHistorySince(days int) int64 {
+	return time.Now().AddDate(0, 0, -days).Unix()
+}
+
+/* Redis store: a sorted set of observations per route, scored by when they were seen */
+type RedisPriceHistoryStore struct {
+	Retention time.Duration
+}
+
+func getPriceHistoryKey(origin string, destination string) string {
+	return "PRICES:" + origin + ":" + destination
+}
+
+func (pvd *RedisPriceHistoryStore) Record(ctx context.Context, observations []models.PriceObservation) error {
+	cache := libs.GetCacheClientInstance()
+	expiredBefore := strconv.FormatInt(time.Now().Add(-pvd.Retention).Unix(), 10)
+
+	_, err := cache.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		routes := make(map[string]bool)
+
+		for _, observation := range observations {
+			data, err := json.Marshal(observation)
+			if err != nil {
+				return err
+			}
+
+			key := getPriceHistoryKey(observation.Origin, observation.Destination)
+			pipe.ZAdd(ctx, key, redis.Z{Score: float64(observation.ObservedAt), Member: data})
+			routes[key] = true
+		}
+
+		// drop what is past retention on every route written to
+		for key := range routes {
+			pipe.ZRemRangeByScore(ctx, key, "-inf", "("+expiredBefore)
+		}
+		return nil
+	})
+
+	return err
+}
+
+func (pvd *RedisPriceHistoryStore) Query(ctx context.Context, query models.PriceHistoryQuery) ([]models.PriceObservation, error) {
+	cache := libs.GetCacheClientInstance()
+	since := getPriceHistorySince(query.Days)
+
+	var observations []models.PriceObservation
+	for _, origin := range query.Origins {
+		for _, destination := range query.Destinations {
+			members, err := cache.ZRangeByScore(ctx, getPriceHistoryKey(origin, destination), &redis.ZRangeBy{
+				Min: strconv.FormatInt(since, 10),
+				Max: "+inf",
+			}).Result()
+			if err != nil {
+				return nil, err
+			}
+
+			for _, member := range members {
+				var observation models.PriceObservation
+				if json.Unmarshal([]byte(member), &observation) == nil && matchesPriceHistoryQuery(observation, query, since) {
+					observations = append(observations, observation)
+				}
+			}
+		}
+	}
+
+	return observations, nil
+}
+
+/*
+File store: one JSON observation per line, for running without Redis persistence.
+The file is read once and queried from memory, and is rewritten without the
+observations past retention at most once per compaction interval.
+*/
+const filePriceHistoryCompactInterval = time.Hour
+
+type FilePriceHistoryStore struct {
+	File      string
+	Retention time.Duration
+
+	mu           sync.Mutex
+	loaded       bool
+	observations []models.PriceObservation
+	compactedAt  time.Time
+}
+
+var (
+	filePriceHistoryStores      = make(map[string]*FilePriceHistoryStore)
+	filePriceHistoryStoresMutex sync.Mutex
+)
+
+// One store per file, so concurrent searches don't interleave their writes
+func getFilePriceHistoryStore(file string, retention time.Duration) *FilePriceHistoryStore {
+	filePriceHistoryStoresMutex.Lock()
+	defer filePriceHistoryStoresMutex.Unlock()
+
+	store, ok := filePriceHistoryStores[file]
+	if !ok {
+		store = &FilePriceHistoryStore{File: file, Retention: retention}
+		filePriceHistoryStores[file] = store
+	}
+	return store
+}
+
+func (pvd *FilePriceHistoryStore) Record(ctx context.Context, observations []models.PriceObservation) error {
+	pvd.mu.Lock()
+	defer pvd.mu.Unlock()
+
+	if err := pvd.load(); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(pvd.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := writePriceObservations(file, observations); err != nil {
+		return err
+	}
+	pvd.observations = append(pvd.observations, observations...)
+
+	if time.Since(pvd.compactedAt) >= filePriceHistoryCompactInterval {
+		return pvd.compact()
+	}
+	return nil
+}
+
+func (pvd *FilePriceHistoryStore) Query(ctx context.Context, query models.PriceHistoryQuery) ([]models.PriceObservation, error) {
+	pvd.mu.Lock()
+	defer pvd.mu.Unlock()
+
+	if err := pvd.load(); err != nil {
+		return nil, err
+	}
+
+	since := max(getPriceHistorySince(query.Days), pvd.getExpiredBefore())
+
+	var observations []models.PriceObservation
+	for _, observation := range pvd.observations {
+		if matchesPriceHistoryQuery(observation, query, since) {
+			observations = append(observations, observation)
+		}
+	}
+
+	return observations, nil
+}
+
+// Read the file into memory on first use, dropping what is past retention
+func (pvd *FilePriceHistoryStore) load() error {
+	if pvd.loaded {
+		return nil
+	}
+
+	file, err := os.Open(pvd.File)
+	if os.IsNotExist(err) {
+		pvd.loaded, pvd.compactedAt = true, time.Now()
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var observations []models.PriceObservation
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var observation models.PriceObservation
+		if json.Unmarshal(scanner.Bytes(), &observation) == nil {
+			observations = append(observations, observation)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	pvd.observations, pvd.loaded = observations, true
+	return pvd.compact()
+}
+
+// Rewrite the file with only the observations within retention
+func (pvd *FilePriceHistoryStore) compact() error {
+	expiredBefore := pvd.getExpiredBefore()
+	pvd.observations = slices.DeleteFunc(pvd.observations, func(observation models.PriceObservation) bool {
+		return observation.ObservedAt < expiredBefore
+	})
+
+	// write a copy and swap it in, so a failed write keeps the old file
+	tempFile := pvd.File + ".tmp"
+	file, err := os.OpenFile(tempFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if err := writePriceObservations(file, pvd.observations); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tempFile, pvd.File); err != nil {
+		return err
+	}
+
+	pvd.compactedAt = time.Now()
+	return nil
+}
+
+func (pvd *FilePriceHistoryStore) getExpiredBefore() int64 {
+	return time.Now().Add(-pvd.Retention).Unix()
+}
+
+func writePriceObservations(file *os.File, observations []models.PriceObservation) error {
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, observation := range observations {
+		if err := encoder.Encode(observation); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
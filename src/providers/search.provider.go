@@ -1,17 +1,17 @@
 package providers
 
 import (
-	"bookcabin-app-go/src/constants"
 	"bookcabin-app-go/src/libs"
 	"bookcabin-app-go/src/models"
+	"bookcabin-app-go/src/references"
 	"bookcabin-app-go/src/utils"
 	"context"
 	"errors"
 	"fmt"
 	"math/rand"
 	"os"
+	"slices"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -29,16 +29,20 @@ type SearchProviderProperty struct {
 }
 
 type SearchProvider interface {
+	Name() string
 	Fetch(ctx *gin.Context, req models.SearchRequest) ([]models.Flight, error)
 }
 
 /* Fetch Simulation */
-func SimulateFetchWithWait(ctx context.Context, pvd SearchProviderProperty) ([]byte, error) {
+// With bypassCache the provider is always called, and the fresh response replaces the cached one
+func SimulateFetchWithWait(ctx context.Context, pvd SearchProviderProperty, bypassCache bool) ([]byte, error) {
 	// fetch from cache here...
 	cache := libs.GetCacheClientInstance()
-	cachedData, err := cache.Get(ctx, pvd.Name).Result()
-	if err == nil {
-		return []byte(cachedData), nil
+	if !bypassCache {
+		cachedData, err := cache.Get(ctx, pvd.Name).Result()
+		if err == nil {
+			return []byte(cachedData), nil
+		}
 	}
 
 	// simulate fetching from provider
@@ -125,28 +129,69 @@ func readMockFile(ctx context.Context, pvd SearchProviderProperty) ([]byte, erro
 	return data, err
 }
 
+// Check a flight's route against the airports expanded from the request
+func isRequestedRoute(req models.SearchRequest, origin string, destination string) bool {
+	origins := req.OriginAirports
+	if len(origins) == 0 {
+		origins = []string{req.Origin}
+	}
+
+	destinations := req.DestinationAirports
+	if len(destinations) == 0 {
+		destinations = []string{req.Destination}
+	}
+
+	return slices.Contains(origins, origin) && slices.Contains(destinations, destination)
+}
+
 func getCity(airport string) string {
-	if city, ok := constants.Cities[airport]; ok {
-		return city
+	if ref, ok := references.GetAirport(airport); ok {
+		return ref.City
 	}
 	return "Unknown city"
 }
 
-func parseBaggageInfo(baggageInfo string) models.Baggage {
-	baggageInfos := strings.Split(baggageInfo, ",")
+func buildEventPoint(airport string, dateTime time.Time) models.EventPoint {
+	return models.EventPoint{
+		Airport:   airport,
+		City:      getCity(airport),
+		DateTime:  utils.FormatDateTime(dateTime, airport),
+		Timestamp: dateTime.Unix(),
+	}
+}
 
-	if len(baggageInfos) < 2 {
-		return models.Baggage{
-			CarryOn: nil,
-			Checked: nil,
-		}
+// Split a flight into legs at each layover airport. Providers using this only
+// report where the flight stops, so the intermediate times are left empty.
+func buildSegmentsFromLayovers(flight models.Flight) []models.Segment {
+	if len(flight.Layovers) == 0 {
+		duration := flight.Duration
+		return []models.Segment{{
+			FlightNumber: flight.FlightNumber,
+			Departure:    flight.Departure,
+			Arrival:      flight.Arrival,
+			Duration:     &duration,
+		}}
 	}
 
-	carryOn := utils.CapitalizeFirst(baggageInfos[0])
-	checked := utils.CapitalizeFirst(baggageInfos[1])
+	segments := make([]models.Segment, 0, len(flight.Layovers)+1)
+	departure := flight.Departure
 
-	return models.Baggage{
-		CarryOn: &carryOn,
-		Checked: &checked,
+	for _, layover := range flight.Layovers {
+		stop := models.EventPoint{
+			Airport: layover.Airport,
+			City:    getCity(layover.Airport),
+		}
+		segments = append(segments, models.Segment{
+			FlightNumber: flight.FlightNumber,
+			Departure:    departure,
+			Arrival:      stop,
+		})
+		departure = stop
 	}
+
+	return append(segments, models.Segment{
+		FlightNumber: flight.FlightNumber,
+		Departure:    departure,
+		Arrival:      flight.Arrival,
+	})
 }
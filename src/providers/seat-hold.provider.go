@@ -0,0 +1,53 @@
+package providers
+
+import (
+	"bookcabin-app-go/src/models"
+	"context"
+)
+
+// SeatHoldProvider is implemented by providers that can keep seats aside for a while
+type SeatHoldProvider interface {
+	Name() string
+	// place a hold for the passengers and return the provider's hold reference
+	HoldSeats(ctx context.Context, flight models.Flight, passengers int) (string, error)
+	ReleaseHold(ctx context.Context, reference string) error
+}
+
+// Held seats stay in the inventory, since searches already take holds off
+// through Redis, but no other hold can take them until they are released
+func (pvd *StubBookingProvider) HoldSeats(ctx context.Context, flight models.Flight, passengers int) (string, error) {
+	pvd.inventory.mu.Lock()
+	defer pvd.inventory.mu.Unlock()
+
+	key := getInventoryKey(flight)
+	seats, ok := pvd.inventory.seats[key]
+	if !ok {
+		seats = flight.AvailableSeats
+	}
+
+	for _, hold := range pvd.inventory.holds {
+		if hold.key == key {
+			seats -= hold.seats
+		}
+	}
+
+	if seats < passengers {
+		return "", ErrNotEnoughSeats
+	}
+
+	reference, err := generateCode(8)
+	if err != nil {
+		return "", err
+	}
+
+	pvd.inventory.holds[reference] = stubReservation{key: key, seats: passengers}
+	return reference, nil
+}
+
+func (pvd *StubBookingProvider) ReleaseHold(ctx context.Context, reference string) error {
+	pvd.inventory.mu.Lock()
+	defer pvd.inventory.mu.Unlock()
+
+	delete(pvd.inventory.holds, reference)
+	return nil
+}
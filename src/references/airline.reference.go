@@ -0,0 +1,114 @@
+package references
+
+import (
+	"bookcabin-app-go/src/libs"
+	"bookcabin-app-go/src/models"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+//go:embed data/airlines.json
+var airlinesData []byte
+
+var (
+	airlinesByCode map[string]models.AirlineReference
+	airlinesByName map[string][]models.AirlineReference
+	airlinesOnce   sync.Once
+)
+
+var airlineNameSeparators = regexp.MustCompile(`[^a-z0-9]+`)
+
+func loadAirlines() {
+	airlinesOnce.Do(func() {
+		var airlines []models.AirlineReference
+		if err := json.Unmarshal(airlinesData, &airlines); err != nil {
+			panic(fmt.Sprintf("invalid airline reference data: %v", err))
+		}
+
+		airlinesByCode = make(map[string]models.AirlineReference, len(airlines)*2)
+		airlinesByName = make(map[string][]models.AirlineReference, len(airlines))
+
+		// a name or alias shared by several airlines, such as "AirAsia", keeps all of them
+		for _, airline := range airlines {
+			airlinesByCode[airline.IATA] = airline
+			airlinesByCode[airline.ICAO] = airline
+
+			for _, name := range append([]string{airline.Name}, airline.Aliases...) {
+				key := normalizeAirlineName(name)
+				airlinesByName[key] = append(airlinesByName[key], airline)
+			}
+		}
+	})
+}
+
+// Find an airline by its IATA or ICAO code
+func GetAirline(code string) (models.AirlineReference, bool) {
+	loadAirlines()
+	airline, ok := airlinesByCode[strings.ToUpper(strings.TrimSpace(code))]
+	return airline, ok
+}
+
+// Find an airline by code, canonical name or one of its aliases. Names shared
+// by several airlines are ambiguous and find none.
+func FindAirline(query string) (models.AirlineReference, bool) {
+	if airline, ok := GetAirline(query); ok {
+		return airline, ok
+	}
+
+	loadAirlines()
+	airlines := airlinesByName[normalizeAirlineName(query)]
+	if len(airlines) != 1 {
+		return models.AirlineReference{}, false
+	}
+	return airlines[0], true
+}
+
+// Tell whether a query is the airline's IATA or ICAO code, canonical name or
+// one of its aliases
+func MatchesAirline(airline models.AirlineReference, query string) bool {
+	query = strings.TrimSpace(query)
+	if strings.EqualFold(query, airline.IATA) || strings.EqualFold(query, airline.ICAO) {
+		return true
+	}
+
+	name := normalizeAirlineName(query)
+	for _, candidate := range append([]string{airline.Name}, airline.Aliases...) {
+		if name == normalizeAirlineName(candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize what a provider sends into the registry's airline identity. The
+// code wins over the name, and unknown airlines keep the provider's values.
+func NormalizeAirline(code string, name string) models.Airline {
+	airline, ok := GetAirline(code)
+	if !ok {
+		airline, ok = FindAirline(name)
+	}
+
+	if !ok {
+		return models.Airline{
+			Name: name,
+			Code: code,
+		}
+	}
+
+	return models.Airline{
+		Name:     airline.Name,
+		Code:     airline.IATA,
+		ICAO:     airline.ICAO,
+		Alliance: airline.Alliance,
+		LogoURL:  libs.GetEnv("AIRLINE_LOGO_BASE_URL", "https://pics.avs.io/200/200/") + airline.IATA + ".png",
+	}
+}
+
+// "Air Asia", "AirAsia" and "airasia" all normalize to "airasia"
+func normalizeAirlineName(name string) string {
+	return airlineNameSeparators.ReplaceAllString(strings.ToLower(name), "")
+}
@@ -0,0 +1,134 @@
+package references
+
+import (
+	"bookcabin-app-go/src/models"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata"
+)
+
+//go:embed data/airports.json
+var airportsData []byte
+
+var (
+	airports         []models.Airport
+	airportsByCode   map[string]models.Airport
+	airportLocations map[string]*time.Location
+	airportsOnce     sync.Once
+)
+
+// Load the embedded airport reference data once. The file ships with the
+// binary, so a malformed file or unknown timezone is a build mistake.
+func loadAirports() {
+	airportsOnce.Do(func() {
+		if err := json.Unmarshal(airportsData, &airports); err != nil {
+			panic(fmt.Sprintf("invalid airport reference data: %v", err))
+		}
+
+		airportsByCode = make(map[string]models.Airport, len(airports)*2)
+		airportLocations = make(map[string]*time.Location, len(airports))
+
+		for _, airport := range airports {
+			loc, err := time.LoadLocation(airport.Timezone)
+			if err != nil {
+				panic(fmt.Sprintf("invalid timezone for airport %s: %v", airport.IATA, err))
+			}
+
+			airportsByCode[airport.IATA] = airport
+			airportsByCode[airport.ICAO] = airport
+			airportLocations[airport.IATA] = loc
+		}
+	})
+}
+
+// Find an airport by its IATA or ICAO code
+func GetAirport(code string) (models.Airport, bool) {
+	loadAirports()
+	airport, ok := airportsByCode[strings.ToUpper(code)]
+	return airport, ok
+}
+
+func GetAirports() []models.Airport {
+	loadAirports()
+	return airports
+}
+
+// Find airports within radiusKm of any of the given airports, excluding the
+// given airports themselves, mapped to the distance from the closest one
+func FindNearbyAirports(codes []string, radiusKm float64) map[string]float64 {
+	nearby := make(map[string]float64)
+
+	if radiusKm <= 0 {
+		return nearby
+	}
+
+	for _, code := range codes {
+		origin, ok := GetAirport(code)
+		if !ok {
+			continue
+		}
+
+		for _, airport := range GetAirports() {
+			if slices.Contains(codes, airport.IATA) {
+				continue
+			}
+
+			distance := DistanceKm(origin, airport)
+			if distance > radiusKm {
+				continue
+			}
+
+			if current, ok := nearby[airport.IATA]; !ok || distance < current {
+				nearby[airport.IATA] = distance
+			}
+		}
+	}
+
+	return nearby
+}
+
+// Great-circle distance between two airports using the haversine formula
+func DistanceKm(a models.Airport, b models.Airport) float64 {
+	const earthRadiusKm = 6371.0
+
+	lat1, lat2 := toRadians(a.Latitude), toRadians(b.Latitude)
+	deltaLat := toRadians(b.Latitude - a.Latitude)
+	deltaLon := toRadians(b.Longitude - a.Longitude)
+
+	h := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(deltaLon/2)*math.Sin(deltaLon/2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+func toRadians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+// City name of an airport in the given locale, falling back to its default name
+func GetCityName(code string, locale string) (string, bool) {
+	airport, ok := GetAirport(code)
+	if !ok {
+		return "", false
+	}
+
+	if name, ok := airport.CityNames[locale]; ok {
+		return name, true
+	}
+	return airport.City, true
+}
+
+// Timezone of an airport, nil when the airport is unknown
+func GetAirportLocation(code string) *time.Location {
+	airport, ok := GetAirport(code)
+	if !ok {
+		return nil
+	}
+	return airportLocations[airport.IATA]
+}
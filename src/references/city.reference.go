@@ -0,0 +1,69 @@
+package references
+
+import (
+	"bookcabin-app-go/src/models"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+//go:embed data/cities.json
+var citiesData []byte
+
+var (
+	metropolitanAreas map[string]models.MetropolitanArea
+	citiesOnce        sync.Once
+)
+
+func loadCities() {
+	citiesOnce.Do(func() {
+		var areas []models.MetropolitanArea
+		if err := json.Unmarshal(citiesData, &areas); err != nil {
+			panic(fmt.Sprintf("invalid city reference data: %v", err))
+		}
+
+		metropolitanAreas = make(map[string]models.MetropolitanArea, len(areas))
+		for _, area := range areas {
+			metropolitanAreas[area.Code] = area
+		}
+	})
+}
+
+func GetMetropolitanArea(code string) (models.MetropolitanArea, bool) {
+	loadCities()
+	area, ok := metropolitanAreas[strings.ToUpper(code)]
+	return area, ok
+}
+
+// Expand an airport code, a metropolitan city code or a comma-separated list
+// of them into the IATA codes of every airport they cover
+func ExpandLocation(location string) ([]string, error) {
+	var airports []string
+
+	for _, code := range strings.Split(location, ",") {
+		code = strings.TrimSpace(code)
+
+		if area, ok := GetMetropolitanArea(code); ok {
+			for _, airport := range area.Airports {
+				if !slices.Contains(airports, airport) {
+					airports = append(airports, airport)
+				}
+			}
+			continue
+		}
+
+		airport, ok := GetAirport(code)
+		if !ok {
+			return nil, fmt.Errorf("unknown airport or city code: %s", code)
+		}
+
+		if !slices.Contains(airports, airport.IATA) {
+			airports = append(airports, airport.IATA)
+		}
+	}
+
+	return airports, nil
+}
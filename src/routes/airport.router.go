@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"bookcabin-app-go/src/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAirportRoutes(router *gin.Engine) {
+	routeGroup := router.Group("/airports")
+	routeGroup.GET("", handlers.SearchAirports)
+}
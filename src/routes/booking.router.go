@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"bookcabin-app-go/src/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterBookingRoutes(router *gin.Engine) {
+	routeGroup := router.Group("/bookings")
+	routeGroup.POST("", handlers.CreateBooking)
+	routeGroup.GET("", handlers.SearchBookings)
+	routeGroup.GET("/:id", handlers.GetBooking)
+	routeGroup.POST("/:id/cancel", handlers.CancelBooking)
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"bookcabin-app-go/src/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterOfferRoutes(router *gin.Engine) {
+	routeGroup := router.Group("/offers")
+	routeGroup.POST("/:flightId/revalidate", handlers.RevalidateOffer)
+	routeGroup.GET("/:flightId/rules", handlers.GetFareRules)
+	routeGroup.GET("/:flightId/ancillaries", handlers.GetAncillaries)
+}
@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"bookcabin-app-go/src/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterPriceHistoryRoutes(router *gin.Engine) {
+	routeGroup := router.Group("/routes")
+	routeGroup.GET("/:origin/:destination/price-history", handlers.GetPriceHistory)
+}
@@ -6,4 +6,9 @@ import (
 
 func RegisterRoutes(router *gin.Engine) {
 	RegisterSearchRoutes(router)
+	RegisterAirportRoutes(router)
+	RegisterOfferRoutes(router)
+	RegisterBookingRoutes(router)
+	RegisterSeatHoldRoutes(router)
+	RegisterPriceHistoryRoutes(router)
 }
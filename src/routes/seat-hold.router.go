@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"bookcabin-app-go/src/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterSeatHoldRoutes(router *gin.Engine) {
+	routeGroup := router.Group("/holds")
+	routeGroup.POST("", handlers.HoldSeats)
+	routeGroup.GET("/:id", handlers.GetSeatHold)
+	routeGroup.DELETE("/:id", handlers.ReleaseSeatHold)
+}
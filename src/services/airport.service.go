@@ -0,0 +1,153 @@
+package services
+
+import (
+	"bookcabin-app-go/src/models"
+	"bookcabin-app-go/src/references"
+	"bookcabin-app-go/src/utils"
+	"cmp"
+	"slices"
+	"strings"
+)
+
+/* Airport autocomplete ranking, the higher the score the better the match */
+const (
+	scoreIataExact   = 100
+	scoreIcaoExact   = 95
+	scoreCityExact   = 90
+	scoreNameExact   = 88
+	scoreRegionExact = 80
+	scoreIataPrefix  = 78
+	scoreCityPrefix  = 75
+	scoreNamePrefix  = 70
+	scoreWordPrefix  = 65
+	scoreSubstring   = 50
+	scoreFuzzy       = 40
+)
+
+type AirportService struct{}
+
+func NewAirportService() *AirportService {
+	return &AirportService{}
+}
+
+func (s *AirportService) Search(query string, countryCode string, limit int) []models.AirportMatch {
+	query = strings.ToLower(strings.TrimSpace(query))
+	results := make([]models.AirportMatch, 0)
+
+	if query == "" {
+		return results
+	}
+
+	for _, airport := range references.GetAirports() {
+		if countryCode != "" && !strings.EqualFold(airport.CountryCode, countryCode) {
+			continue
+		}
+
+		score, matchedOn := scoreAirportMatch(airport, query)
+		if score == 0 {
+			continue
+		}
+
+		results = append(results, models.AirportMatch{
+			Airport:   airport,
+			Score:     score,
+			MatchedOn: matchedOn,
+		})
+	}
+
+	slices.SortStableFunc(results, func(a, b models.AirportMatch) int {
+		return cmp.Or(
+			cmp.Compare(b.Score, a.Score),
+			cmp.Compare(a.City, b.City),
+			cmp.Compare(a.IATA, b.IATA),
+		)
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results
+}
+
+// Score a single airport against the query, returning the best matching field
+func scoreAirportMatch(airport models.Airport, query string) (float64, string) {
+	iata := strings.ToLower(airport.IATA)
+	icao := strings.ToLower(airport.ICAO)
+	name := strings.ToLower(airport.Name)
+	city := strings.ToLower(airport.City)
+	region := strings.ToLower(airport.Region)
+
+	switch {
+	case query == iata:
+		return scoreIataExact, "iata"
+	case query == icao:
+		return scoreIcaoExact, "icao"
+	case query == city:
+		return scoreCityExact, "city"
+	case query == name:
+		return scoreNameExact, "name"
+	case query == region:
+		return scoreRegionExact, "region"
+	case strings.HasPrefix(iata, query):
+		return scoreIataPrefix, "iata"
+	case strings.HasPrefix(city, query):
+		return scoreCityPrefix, "city"
+	case strings.HasPrefix(name, query):
+		return scoreNamePrefix, "name"
+	case hasWordWithPrefix(name, query):
+		return scoreWordPrefix, "name"
+	case hasWordWithPrefix(city, query) || hasWordWithPrefix(region, query):
+		return scoreWordPrefix, "city"
+	case strings.Contains(city, query):
+		return scoreSubstring, "city"
+	case strings.Contains(name, query):
+		return scoreSubstring, "name"
+	}
+
+	return scoreFuzzyMatch(query, city, name, region)
+}
+
+func hasWordWithPrefix(text string, prefix string) bool {
+	for _, word := range strings.Fields(text) {
+		if strings.HasPrefix(word, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Tolerate typos: one edit for short queries, two for longer ones
+func scoreFuzzyMatch(query string, city string, name string, region string) (float64, string) {
+	maxDistance := 1
+	if len(query) >= 6 {
+		maxDistance = 2
+	}
+
+	if len(query) < 3 {
+		return 0, ""
+	}
+
+	candidates := map[string][]string{
+		"city":   append([]string{city}, strings.Fields(city)...),
+		"region": append([]string{region}, strings.Fields(region)...),
+		"name":   strings.Fields(name),
+	}
+
+	bestScore, bestField := 0.0, ""
+	for _, field := range []string{"city", "region", "name"} {
+		for _, candidate := range candidates[field] {
+			distance := utils.LevenshteinDistance(query, candidate)
+			if distance > maxDistance {
+				continue
+			}
+
+			score := float64(scoreFuzzy - distance*10)
+			if score > bestScore {
+				bestScore, bestField = score, field
+			}
+		}
+	}
+
+	return bestScore, bestField
+}
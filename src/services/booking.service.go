@@ -0,0 +1,414 @@
+package services
+
+import (
+	"bookcabin-app-go/src/libs"
+	"bookcabin-app-go/src/models"
+	"bookcabin-app-go/src/providers"
+	"bookcabin-app-go/src/utils"
+	"cmp"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	BookingStatusPending   = "pending"
+	BookingStatusConfirmed = "confirmed"
+	BookingStatusTicketed  = "ticketed"
+	BookingStatusCancelled = "cancelled"
+)
+
+// how long a cancellation keeps concurrent ones out, in case it never finishes
+const bookingCancelClaimTTL = time.Minute
+
+var (
+	ErrOfferUnavailable      = errors.New("offer is no longer available")
+	ErrPriceChanged          = errors.New("offer price has changed")
+	ErrBookingNotFound       = errors.New("booking not found")
+	ErrBookingNotCancellable = errors.New("booking cannot be cancelled")
+)
+
+type BookingService struct {
+	offers *OfferService
+	promos *PromoService
+	holds  *SeatHoldService
+}
+
+func NewBookingService() *BookingService {
+	return &BookingService{
+		offers: NewOfferService(),
+		promos: NewPromoService(),
+		holds:  NewSeatHoldService(),
+	}
+}
+
+// Book the offer behind a verified token. The offer is revalidated first, so a
+// stale fare or a sold out flight is never booked. The booking is stored as
+// pending, confirmed once the provider returns a PNR and ticketed once tickets
+// are issued; a provider failure cancels it. Booking a held offer ends its hold.
+func (s *BookingService) Create(
+	ctx *gin.Context,
+	offer models.Offer,
+	req models.BookingRequest,
+	locale string,
+) (booking models.Booking, err error) {
+	provider := s.getBookingProvider(offer.Provider)
+	if provider == nil {
+		return models.Booking{}, ErrOfferNotFound
+	}
+
+	revalidated, err := s.offers.Revalidate(ctx, offer.FlightID, offer, locale, false)
+	if err != nil {
+		return models.Booking{}, err
+	}
+
+	if !revalidated.Available {
+		return models.Booking{}, ErrOfferUnavailable
+	}
+
+	if revalidated.PriceStatus != PriceStatusUnchanged {
+		return models.Booking{}, fmt.Errorf("%w from %s to %s, revalidate to get the new offer token",
+			ErrPriceChanged, revalidated.PreviousPrice.Formatted, revalidated.CurrentPrice.Formatted)
+	}
+
+	// an expired hold no longer keeps seats aside, the booking then competes for them
+	var holdReference string
+	if offer.HoldID != "" {
+		hold, err := s.holds.Get(ctx, offer.HoldID)
+		if err != nil && !errors.Is(err, ErrSeatHoldNotFound) {
+			return models.Booking{}, err
+		}
+		holdReference = hold.ProviderReference
+	}
+
+	booking, err = newBooking(revalidated, req, offer, locale)
+	if err != nil {
+		return booking, err
+	}
+
+	if err := saveBooking(ctx, &booking); err != nil {
+		return booking, err
+	}
+
+	// the promo is redeemed once the pending booking is stored, and given back
+	// on every failure after that
+	if promotion, ok := utils.GetPromotion(offer.PromoCode); ok {
+		if err := s.promos.Redeem(ctx, promotion); err != nil {
+			setBookingStatus(&booking, BookingStatusCancelled, "promo code "+promotion.Code+" is no longer available")
+			return booking, errors.Join(err, saveBooking(ctx, &booking))
+		}
+
+		defer func() {
+			if err != nil {
+				s.promos.Release(ctx, promotion.Code)
+			}
+		}()
+	}
+
+	pnr, err := provider.Book(ctx, models.ProviderBookingRequest{
+		Flight:        revalidated.Flight,
+		Passengers:    req.Passengers,
+		Contact:       req.Contact,
+		HoldReference: holdReference,
+	})
+	if err != nil {
+		setBookingStatus(&booking, BookingStatusCancelled, "rejected by "+booking.Provider+": "+err.Error())
+		saveErr := saveBooking(ctx, &booking)
+
+		if errors.Is(err, providers.ErrNotEnoughSeats) {
+			err = ErrOfferUnavailable
+		}
+		return booking, errors.Join(err, saveErr)
+	}
+
+	booking.PNR = pnr
+	setBookingStatus(&booking, BookingStatusConfirmed, "PNR "+pnr+" confirmed by "+booking.Provider)
+	if err = saveBooking(ctx, &booking); err != nil {
+		// a PNR the booking doesn't record would hold its seats for good
+		if cancelErr := provider.Cancel(ctx, pnr); cancelErr != nil {
+			log.Printf("booking %s: cancel unsaved PNR %s with %s: %v", booking.ID, pnr, booking.Provider, cancelErr)
+			return booking, errors.Join(err, cancelErr)
+		}
+
+		setBookingStatus(&booking, BookingStatusCancelled, "PNR "+pnr+" cancelled, the booking could not be saved")
+		return booking, errors.Join(err, saveBooking(ctx, &booking))
+	}
+
+	// the booked seats no longer need holding
+	if offer.HoldID != "" {
+		s.holds.release(ctx, offer.HoldID, SeatHoldEventConverted)
+	}
+
+	// a booking that could not be ticketed yet stays confirmed
+	tickets, ticketErr := provider.IssueTickets(ctx, pnr, len(booking.Passengers))
+	if ticketErr == nil && len(tickets) != len(booking.Passengers) {
+		ticketErr = fmt.Errorf("got %d tickets for %d passengers", len(tickets), len(booking.Passengers))
+	}
+	if ticketErr != nil {
+		log.Printf("booking %s: issue tickets for PNR %s: %v", booking.ID, pnr, ticketErr)
+		return booking, nil
+	}
+
+	for i := range booking.Passengers {
+		booking.Passengers[i].TicketNumber = tickets[i]
+	}
+	setBookingStatus(&booking, BookingStatusTicketed, "tickets issued")
+
+	// the stored booking stays confirmed, so the promo is kept
+	if saveErr := saveBooking(ctx, &booking); saveErr != nil {
+		log.Printf("booking %s: save tickets for PNR %s: %v", booking.ID, pnr, saveErr)
+	}
+	return booking, nil
+}
+
+func (s *BookingService) getBookingProvider(name string) providers.BookingProvider {
+	if provider, ok := s.offers.search.getProvider(name).(providers.BookingProvider); ok {
+		return provider
+	}
+	return nil
+}
+
+func newBooking(
+	revalidated models.RevalidateResponse,
+	req models.BookingRequest,
+	offer models.Offer,
+	locale string,
+) (models.Booking, error) {
+	idBytes := make([]byte, 5)
+	if _, err := rand.Read(idBytes); err != nil {
+		return models.Booking{}, err
+	}
+
+	accessTokenBytes := make([]byte, 32)
+	if _, err := rand.Read(accessTokenBytes); err != nil {
+		return models.Booking{}, err
+	}
+	accessToken := base64.RawURLEncoding.EncodeToString(accessTokenBytes)
+
+	fare := revalidated.CurrentPrice
+	totalAmount := utils.RoundAmount(fare.Amount*float64(len(req.Passengers)), fare.Currency)
+
+	flight := revalidated.Flight
+	flight.OfferToken = ""
+
+	passengers := make([]models.BookingPassenger, 0, len(req.Passengers))
+	for _, passenger := range req.Passengers {
+		passengers = append(passengers, models.BookingPassenger{
+			Title:              passenger.Title,
+			FirstName:          passenger.FirstName,
+			LastName:           passenger.LastName,
+			DateOfBirth:        passenger.DateOfBirth,
+			Nationality:        passenger.Nationality,
+			DocumentType:       passenger.DocumentType,
+			DocumentNumber:     passenger.DocumentNumber,
+			DocumentExpiryDate: passenger.DocumentExpiryDate,
+		})
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	booking := models.Booking{
+		ID:         fmt.Sprintf("BK%X", idBytes),
+		Provider:   offer.Provider,
+		Flight:     flight,
+		Passengers: passengers,
+		Contact: models.BookingContact{
+			Email: req.Contact.Email,
+			Phone: req.Contact.Phone,
+		},
+		Fare: fare,
+		TotalPrice: models.Price{
+			Amount:    totalAmount,
+			Currency:  fare.Currency,
+			Formatted: utils.FormatLocalizedPrice(totalAmount, fare.Currency, locale),
+		},
+		PromoCode: offer.PromoCode,
+		Locale:    locale,
+		CreatedAt: now,
+		UpdatedAt: now,
+
+		AccessToken:     accessToken,
+		AccessTokenHash: hashBookingAccessToken(accessToken),
+	}
+	setBookingStatus(&booking, BookingStatusPending, "booking created")
+
+	return booking, nil
+}
+
+// Move a booking to a new status, keeping the change in its audit trail
+func setBookingStatus(booking *models.Booking, status string, note string) {
+	booking.Status = status
+	booking.History = append(booking.History, models.BookingEvent{
+		Status: status,
+		Note:   note,
+		At:     time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+func (s *BookingService) Get(ctx context.Context, id string) (models.Booking, error) {
+	var booking models.Booking
+
+	data, err := libs.GetCacheClientInstance().Get(ctx, getBookingKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return booking, ErrBookingNotFound
+	}
+	if err != nil {
+		return booking, err
+	}
+
+	err = json.Unmarshal(data, &booking)
+	return booking, err
+}
+
+// Bookings made with a contact email, newest first
+func (s *BookingService) FindByEmail(ctx context.Context, email string) ([]models.Booking, error) {
+	ids, err := libs.GetCacheClientInstance().SMembers(ctx, getBookingEmailKey(email)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	bookings := make([]models.Booking, 0, len(ids))
+	for _, id := range ids {
+		booking, err := s.Get(ctx, id)
+		if errors.Is(err, ErrBookingNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		bookings = append(bookings, booking)
+	}
+
+	slices.SortFunc(bookings, func(a, b models.Booking) int {
+		return cmp.Or(cmp.Compare(b.CreatedAt, a.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+
+	return bookings, nil
+}
+
+// Cancel with the airline and refund under the fare's rules. The refund
+// is worked out before calling the airline, so a missing FX rate doesn't leave
+// a booking cancelled without a refund amount.
+func (s *BookingService) Cancel(ctx *gin.Context, id string, reason string) (booking models.Booking, err error) {
+	// claim the cancellation first, so concurrent cancels can't both refund it.
+	// A failed cancellation gives the claim back to be retried.
+	cache := libs.GetCacheClientInstance()
+	claimKey := getBookingCancelKey(id)
+
+	claimed, err := cache.SetNX(ctx, claimKey, 1, bookingCancelClaimTTL).Result()
+	if err != nil {
+		return booking, err
+	}
+	if !claimed {
+		return booking, fmt.Errorf("%w, it is already being cancelled", ErrBookingNotCancellable)
+	}
+
+	defer func() {
+		if err != nil {
+			cache.Del(ctx, claimKey)
+		}
+	}()
+
+	booking, err = s.Get(ctx, id)
+	if err != nil {
+		return booking, err
+	}
+
+	if booking.Status != BookingStatusConfirmed && booking.Status != BookingStatusTicketed {
+		return booking, fmt.Errorf("%w, it is %s", ErrBookingNotCancellable, booking.Status)
+	}
+
+	provider := s.getBookingProvider(booking.Provider)
+	if provider == nil {
+		return booking, fmt.Errorf("no booking provider for %s", booking.Provider)
+	}
+
+	getRates := sync.OnceValues(func() (models.FxRates, error) {
+		return s.offers.search.fxRates.GetRates(ctx)
+	})
+
+	rule, _ := utils.GetFareRule(booking.Flight.Airline.Code, booking.Flight.FareType)
+	refund, err := utils.CalculateRefund(booking, rule, getRates)
+	if err != nil {
+		return booking, err
+	}
+
+	if err := provider.Cancel(ctx, booking.PNR); err != nil {
+		return booking, err
+	}
+
+	note := "cancelled, refund " + refund.Amount.Formatted
+	if reason != "" {
+		note += ": " + reason
+	}
+
+	booking.Refund = &refund
+	setBookingStatus(&booking, BookingStatusCancelled, note)
+
+	if booking.PromoCode != "" {
+		s.promos.Release(ctx, booking.PromoCode)
+	}
+
+	return booking, saveBooking(ctx, &booking)
+}
+
+// Whether a token is the one issued when the booking was created
+func VerifyBookingAccessToken(booking models.Booking, token string) bool {
+	if token == "" || booking.AccessTokenHash == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(hashBookingAccessToken(token)), []byte(booking.AccessTokenHash)) == 1
+}
+
+func hashBookingAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func getBookingKey(id string) string {
+	return "BOOKING:" + id
+}
+
+func getBookingCancelKey(id string) string {
+	return "BOOKING:CANCEL:" + id
+}
+
+func getBookingEmailKey(email string) string {
+	return "BOOKING:EMAIL:" + strings.ToLower(email)
+}
+
+// Store the booking and index it by contact email. The access token itself is
+// never stored, only its hash.
+func saveBooking(ctx context.Context, booking *models.Booking) error {
+	booking.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	stored := *booking
+	stored.AccessToken = ""
+
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+
+	cache := libs.GetCacheClientInstance()
+	if err := cache.Set(ctx, getBookingKey(booking.ID), data, 0).Err(); err != nil {
+		return err
+	}
+
+	return cache.SAdd(ctx, getBookingEmailKey(booking.Contact.Email), booking.ID).Err()
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"bookcabin-app-go/src/models"
+	"bookcabin-app-go/src/providers"
+	"bookcabin-app-go/src/utils"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	PriceStatusUnchanged = "unchanged"
+	PriceStatusIncreased = "increased"
+	PriceStatusDecreased = "decreased"
+)
+
+var (
+	ErrOfferNotFound = errors.New("offer not found")
+	ErrOfferMismatch = errors.New("offer token is for another flight")
+)
+
+type OfferService struct {
+	search *SearchService
+}
+
+func NewOfferService() *OfferService {
+	return &OfferService{search: NewSearchService()}
+}
+
+// Ask the owning provider for the offered flight again, skipping every cache, and
+// reprice it the way search does so it compares with the fare in the offer token
+func (s *OfferService) Revalidate(
+	ctx *gin.Context,
+	flightId string,
+	offer models.Offer,
+	locale string,
+	internal bool,
+) (models.RevalidateResponse, error) {
+	res, _, err := s.revalidate(ctx, flightId, offer, locale, internal)
+	return res, err
+}
+
+// Revalidate, also returning the seats the provider reports before holds are
+// taken off, which seat holds check again when they are placed
+func (s *OfferService) revalidate(
+	ctx *gin.Context,
+	flightId string,
+	offer models.Offer,
+	locale string,
+	internal bool,
+) (models.RevalidateResponse, int, error) {
+	if offer.FlightID != flightId {
+		return models.RevalidateResponse{}, 0, ErrOfferMismatch
+	}
+
+	provider := s.search.getProvider(offer.Provider)
+	if provider == nil {
+		return models.RevalidateResponse{}, 0, ErrOfferNotFound
+	}
+
+	req := offer.SearchRequest()
+	req.Locale = locale
+	req.Internal = internal
+	req.BypassCache = true
+	req.OriginAirports = []string{offer.Origin}
+	req.DestinationAirports = []string{offer.Destination}
+
+	// fetch for one passenger, so a flight without enough seats left is
+	// reported as unavailable rather than not found
+	fetchReq := req
+	fetchReq.Passengers = 1
+
+	flights, err := provider.Fetch(ctx, fetchReq)
+	if err != nil {
+		return models.RevalidateResponse{}, 0, err
+	}
+
+	var found []models.Flight
+	for _, flight := range flights {
+		if flight.ID == flightId {
+			found = append(found, flight)
+			break
+		}
+	}
+
+	if len(found) == 0 {
+		return models.RevalidateResponse{}, 0, ErrOfferNotFound
+	}
+
+	providerSeats := found[0].AvailableSeats
+
+	// seats held by others are not for sale, the offer's own hold is
+	if err := applySeatHolds(ctx, found, offer.HoldID); err != nil {
+		return models.RevalidateResponse{}, 0, err
+	}
+
+	utils.ApplyFareRuleSummaries(found)
+
+	getRates := sync.OnceValues(func() (models.FxRates, error) {
+		return s.search.fxRates.GetRates(ctx)
+	})
+
+	if err := applySellingPrices(found, req, getRates); err != nil {
+		return models.RevalidateResponse{}, 0, err
+	}
+
+	if req.PromoCode != "" {
+		if _, err := s.search.applyPromoCode(ctx, found, req, getRates); err != nil {
+			return models.RevalidateResponse{}, 0, err
+		}
+	}
+
+	flight := found[0]
+	difference := utils.RoundAmount(utils.GetEffectivePrice(flight)-offer.Fare, offer.Currency)
+
+	res := models.RevalidateResponse{
+		FlightID:       flight.ID,
+		Provider:       flight.Provider,
+		Available:      flight.AvailableSeats >= req.Passengers,
+		AvailableSeats: flight.AvailableSeats,
+		Passengers:     req.Passengers,
+		PriceStatus:    getPriceStatus(difference),
+		PreviousPrice: models.Price{
+			Amount:    offer.Fare,
+			Currency:  offer.Currency,
+			Formatted: utils.FormatLocalizedPrice(offer.Fare, offer.Currency, req.Locale),
+		},
+		PriceDifference: difference,
+	}
+
+	// only bookable offers get a fresh token, for the current fare
+	if res.Available {
+		newOffer := utils.NewOffer(flight, req)
+		newOffer.HoldID = offer.HoldID
+
+		var expiresAt int64
+		flight.OfferToken, expiresAt, err = utils.IssueOfferToken(newOffer)
+		if err != nil {
+			return models.RevalidateResponse{}, 0, err
+		}
+
+		res.OfferToken = flight.OfferToken
+		res.ExpiresAt = time.Unix(expiresAt, 0).UTC().Format(time.RFC3339)
+	}
+
+	found[0] = flight
+	utils.LocalizeFlights(found, req.Locale)
+	if !req.Internal {
+		utils.HidePricingBreakdowns(found)
+	}
+
+	res.Flight = found[0]
+	res.CurrentPrice = res.Flight.Price
+	if res.Flight.DiscountedPrice != nil {
+		res.CurrentPrice = *res.Flight.DiscountedPrice
+	}
+
+	return res, providerSeats, nil
+}
+
+func getPriceStatus(difference float64) string {
+	switch {
+	case difference > 0:
+		return PriceStatusIncreased
+	case difference < 0:
+		return PriceStatusDecreased
+	}
+	return PriceStatusUnchanged
+}
+
+// Rules of the offered fare, with each fee worked out for one passenger on the
+// fare in the offer token. They come from the config, so no provider is called.
+func (s *OfferService) GetFareRules(
+	ctx *gin.Context,
+	flightId string,
+	offer models.Offer,
+	locale string,
+) (models.FareRulesResponse, error) {
+	if offer.FlightID != flightId {
+		return models.FareRulesResponse{}, ErrOfferMismatch
+	}
+
+	rule, source := utils.GetFareRule(offer.Airline, offer.FareType)
+
+	getRates := sync.OnceValues(func() (models.FxRates, error) {
+		return s.search.fxRates.GetRates(ctx)
+	})
+
+	// a fee for each allowed action, none for what the fare doesn't allow
+	getFee := func(allowed bool, fee models.FareFee) (*models.Price, error) {
+		if !allowed {
+			return nil, nil
+		}
+
+		amount, err := utils.CalculateFareFee(fee, offer.Fare, 1, offer.Currency, getRates)
+		if err != nil {
+			return nil, err
+		}
+
+		return &models.Price{
+			Amount:    amount,
+			Currency:  offer.Currency,
+			Formatted: utils.FormatLocalizedPrice(amount, offer.Currency, locale),
+		}, nil
+	}
+
+	cancellationFee, err := getFee(rule.Refundable, rule.CancellationFee)
+	if err != nil {
+		return models.FareRulesResponse{}, err
+	}
+
+	changeFee, err := getFee(rule.Changeable, rule.ChangeFee)
+	if err != nil {
+		return models.FareRulesResponse{}, err
+	}
+
+	noShowFee, err := getFee(rule.NoShow.Refundable, rule.NoShow.Fee)
+	if err != nil {
+		return models.FareRulesResponse{}, err
+	}
+
+	return models.FareRulesResponse{
+		FlightID:     offer.FlightID,
+		Provider:     offer.Provider,
+		FlightNumber: offer.FlightNumber,
+		Airline:      offer.Airline,
+		FareType:     offer.FareType,
+		Fare: models.Price{
+			Amount:    offer.Fare,
+			Currency:  offer.Currency,
+			Formatted: utils.FormatLocalizedPrice(offer.Fare, offer.Currency, locale),
+		},
+		Source:          source,
+		Refundable:      rule.Refundable,
+		CancellationFee: cancellationFee,
+		Changeable:      rule.Changeable,
+		ChangeFee:       changeFee,
+		NoShow: models.NoShowFees{
+			Refundable: rule.NoShow.Refundable,
+			Fee:        noShowFee,
+		},
+		Baggage: getFareBaggage(rule.Baggage),
+	}, nil
+}
+
+// Rules describe baggage like providers do, e.g. "7 kg" or "Not included"
+func getFareBaggage(baggage models.FareBaggageRule) models.Baggage {
+	return utils.NewBaggage(
+		utils.ParseBaggageAllowance(baggage.CarryOn),
+		utils.ParseBaggageAllowance(baggage.Checked),
+		"",
+	)
+}
+
+// Extras sold with the offered flight, priced per passenger in the offer's currency
+func (s *OfferService) GetAncillaries(
+	ctx *gin.Context,
+	flightId string,
+	offer models.Offer,
+	locale string,
+) (models.AncillariesResponse, error) {
+	if offer.FlightID != flightId {
+		return models.AncillariesResponse{}, ErrOfferMismatch
+	}
+
+	if s.search.getProvider(offer.Provider) == nil {
+		return models.AncillariesResponse{}, ErrOfferNotFound
+	}
+
+	getRates := sync.OnceValues(func() (models.FxRates, error) {
+		return s.search.fxRates.GetRates(ctx)
+	})
+
+	ancillaries, err := s.search.getAncillaries(ctx, offer, offer.Currency, getRates)
+	if err != nil {
+		return models.AncillariesResponse{}, err
+	}
+
+	res := models.AncillariesResponse{
+		FlightID: offer.FlightID,
+		Provider: offer.Provider,
+		Currency: offer.Currency,
+		Baggage:  []models.Ancillary{},
+		Seats:    []models.Ancillary{},
+		Meals:    []models.Ancillary{},
+	}
+
+	for _, ancillary := range ancillaries {
+		ancillary.Price.Formatted = utils.FormatLocalizedPrice(ancillary.Price.Amount, ancillary.Price.Currency, locale)
+
+		switch ancillary.Type {
+		case providers.AncillaryTypeBaggage:
+			res.Baggage = append(res.Baggage, ancillary)
+		case providers.AncillaryTypeSeat:
+			res.Seats = append(res.Seats, ancillary)
+		case providers.AncillaryTypeMeal:
+			res.Meals = append(res.Meals, ancillary)
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"bookcabin-app-go/src/constants"
+	"bookcabin-app-go/src/libs"
+	"bookcabin-app-go/src/models"
+	"bookcabin-app-go/src/providers"
+	"bookcabin-app-go/src/references"
+	"bookcabin-app-go/src/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const priceHistoryRecordTimeout = 5 * time.Second
+
+type PriceHistoryService struct {
+	store providers.PriceHistoryStore
+}
+
+func NewPriceHistoryService() *PriceHistoryService {
+	return &PriceHistoryService{store: providers.NewPriceHistoryStore()}
+}
+
+// Keep the selling price of every flight a search found, before promo codes
+// and filters, under the airports and local date it departs on. Recording runs
+// in the background, so searches don't wait on the store; the observations are
+// taken first, since the search goes on to change the flights.
+func (s *PriceHistoryService) RecordAsync(flights []models.Flight) {
+	observations := newPriceObservations(flights)
+	if len(observations) == 0 {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), priceHistoryRecordTimeout)
+		defer cancel()
+
+		if err := s.record(ctx, observations); err != nil {
+			log.Printf("record price history: %v", err)
+		}
+	}()
+}
+
+// Every search variant (sort, filters, scoring) misses the search cache on its
+// own, so only the first observation of a flight, departure date, provider and
+// currency per PRICE_HISTORY_BUCKET_IN_S is stored, claimed in Redis. Claims
+// are given back when the store fails, so a later search records them.
+func (s *PriceHistoryService) record(ctx context.Context, observations []models.PriceObservation) error {
+	bucketInSeconds, _ := strconv.Atoi(libs.GetEnv("PRICE_HISTORY_BUCKET_IN_S", "3600"))
+	bucket := time.Duration(max(bucketInSeconds, 1)) * time.Second
+
+	cache := libs.GetCacheClientInstance()
+	cmds, err := cache.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, observation := range observations {
+			pipe.SetNX(ctx, getPriceObservationKey(observation, bucket), 1, bucket)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	firstSeen := make([]models.PriceObservation, 0, len(observations))
+	var claimed []string
+	for i, cmd := range cmds {
+		if cmd.(*redis.BoolCmd).Val() {
+			firstSeen = append(firstSeen, observations[i])
+			claimed = append(claimed, getPriceObservationKey(observations[i], bucket))
+		}
+	}
+
+	if len(firstSeen) == 0 {
+		return nil
+	}
+
+	if err := s.store.Record(ctx, firstSeen); err != nil {
+		return errors.Join(err, cache.Del(ctx, claimed...).Err())
+	}
+	return nil
+}
+
+func getPriceObservationKey(observation models.PriceObservation, bucket time.Duration) string {
+	return fmt.Sprintf("PRICES:SEEN:%s:%s:%s:%s:%d",
+		observation.Provider,
+		observation.FlightID,
+		observation.DepartureDate,
+		observation.Currency,
+		observation.ObservedAt/int64(bucket.Seconds()),
+	)
+}
+
+func newPriceObservations(flights []models.Flight) []models.PriceObservation {
+	observedAt := time.Now().Unix()
+
+	observations := make([]models.PriceObservation, 0, len(flights))
+	for _, flight := range flights {
+		departure, err := time.Parse(constants.GA_DateTimeLayout, flight.Departure.DateTime)
+		if err != nil {
+			continue
+		}
+
+		observations = append(observations, models.PriceObservation{
+			Origin:        flight.Departure.Airport,
+			Destination:   flight.Arrival.Airport,
+			DepartureDate: departure.Format(time.DateOnly),
+			FlightID:      flight.ID,
+			Provider:      flight.Provider,
+			Amount:        flight.Price.Amount,
+			Currency:      flight.Price.Currency,
+			ObservedAt:    observedAt,
+		})
+	}
+
+	return observations
+}
+
+// Daily prices of a route, and whether the latest ones are low, typical or high
+func (s *PriceHistoryService) GetHistory(
+	ctx context.Context,
+	origin string,
+	destination string,
+	query models.PriceHistoryQuery,
+	locale string,
+) (models.PriceHistoryResponse, error) {
+	observations, err := s.store.Query(ctx, query)
+	if err != nil {
+		return models.PriceHistoryResponse{}, err
+	}
+
+	loc := time.UTC
+	if tz := references.GetAirportLocation(query.Origins[0]); tz != nil {
+		loc = tz
+	}
+
+	days := utils.SummarizePriceHistory(observations, query.Currency, loc)
+
+	res := models.PriceHistoryResponse{
+		Origin:        origin,
+		Destination:   destination,
+		DepartureDate: query.DepartureDate,
+		FlightID:      query.FlightID,
+		Currency:      query.Currency,
+		Days:          days,
+	}
+
+	// prices of different departure dates aren't comparable, so only one
+	// departure date gets a price level
+	if query.DepartureDate != "" {
+		res.PriceLevel = utils.GetPriceLevel(days)
+	}
+
+	if len(days) > 0 {
+		current := days[len(days)-1].Min
+		res.CurrentPrice = &models.Price{
+			Amount:    current,
+			Currency:  query.Currency,
+			Formatted: utils.FormatLocalizedPrice(current, query.Currency, locale),
+		}
+	}
+
+	return res, nil
+}
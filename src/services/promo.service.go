@@ -0,0 +1,113 @@
+package services
+
+import (
+	"bookcabin-app-go/src/libs"
+	"bookcabin-app-go/src/models"
+	"bookcabin-app-go/src/utils"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const maxPromoReleaseAttempts = 5
+
+var ErrPromoUsageLimitReached = errors.New("promo code usage limit reached")
+
+// PromoService keeps promo code redemptions in Redis, so usage limits hold across instances
+type PromoService struct{}
+
+func NewPromoService() *PromoService {
+	return &PromoService{}
+}
+
+func getPromoUsageKey(code string) string {
+	return "PROMO:USAGE:" + strings.ToUpper(code)
+}
+
+// Look up a promo code and check its sale period and usage limit.
+// The status is empty when the code can be applied.
+func (s *PromoService) CheckPromoCode(ctx context.Context, code string) (models.Promotion, string) {
+	promotion, ok := utils.GetPromotion(code)
+	if !ok {
+		return promotion, utils.PromoStatusInvalid
+	}
+
+	if status := utils.GetPromotionPeriodStatus(promotion, time.Now()); status != "" {
+		return promotion, status
+	}
+
+	if promotion.UsageLimit > 0 {
+		usage, err := s.GetUsage(ctx, code)
+		if err != nil {
+			return promotion, utils.PromoStatusUnavailable
+		}
+
+		if usage >= promotion.UsageLimit {
+			return promotion, utils.PromoStatusUsageLimitReached
+		}
+	}
+
+	return promotion, ""
+}
+
+func (s *PromoService) GetUsage(ctx context.Context, code string) (int64, error) {
+	usage, err := libs.GetCacheClientInstance().Get(ctx, getPromoUsageKey(code)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return usage, err
+}
+
+// Count one redemption, refusing it once the usage limit is reached
+func (s *PromoService) Redeem(ctx context.Context, promotion models.Promotion) error {
+	cache := libs.GetCacheClientInstance()
+	key := getPromoUsageKey(promotion.Code)
+
+	usage, err := cache.Incr(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+
+	if promotion.UsageLimit > 0 && usage > promotion.UsageLimit {
+		cache.Decr(ctx, key)
+		return ErrPromoUsageLimitReached
+	}
+
+	return nil
+}
+
+// Give a redemption back, e.g. when a booking is cancelled. Usage never goes
+// below zero, even when a redemption is given back twice.
+func (s *PromoService) Release(ctx context.Context, code string) error {
+	cache := libs.GetCacheClientInstance()
+	key := getPromoUsageKey(code)
+
+	var err error
+	for range maxPromoReleaseAttempts {
+		err = cache.Watch(ctx, func(tx *redis.Tx) error {
+			usage, err := tx.Get(ctx, key).Int64()
+			if errors.Is(err, redis.Nil) || (err == nil && usage <= 0) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Decr(ctx, key)
+				return nil
+			})
+			return err
+		}, key)
+
+		// a redemption changed the usage while checking, check again
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+
+	return err
+}
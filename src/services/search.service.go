@@ -4,10 +4,15 @@ import (
 	"bookcabin-app-go/src/libs"
 	"bookcabin-app-go/src/models"
 	"bookcabin-app-go/src/providers"
+	"bookcabin-app-go/src/references"
 	"bookcabin-app-go/src/utils"
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"log"
+	"maps"
+	"math"
+	"slices"
 	"sync"
 	"time"
 
@@ -16,15 +21,28 @@ import (
 
 type SearchService struct {
 	providers []providers.SearchProvider
+	fxRates   providers.FxRateProvider
 }
 
 func NewSearchService() *SearchService {
-	return &SearchService{[]providers.SearchProvider{
-		providers.NewAirAsiaProvider(),
-		providers.NewBatikAirProvider(),
-		providers.NewGarudaIndonesiaProvider(),
-		providers.NewLionAirProvider(),
-	}}
+	return &SearchService{
+		providers: []providers.SearchProvider{
+			providers.NewAirAsiaProvider(),
+			providers.NewBatikAirProvider(),
+			providers.NewGarudaIndonesiaProvider(),
+			providers.NewLionAirProvider(),
+		},
+		fxRates: providers.NewFxRateProvider(),
+	}
+}
+
+func (s *SearchService) getProvider(name string) providers.SearchProvider {
+	for _, p := range s.providers {
+		if p.Name() == name {
+			return p
+		}
+	}
+	return nil
 }
 
 func (s *SearchService) Search(ctx *gin.Context, req models.SearchRequest) (models.SearchResponse, error) {
@@ -40,10 +58,29 @@ func (s *SearchService) Search(ctx *gin.Context, req models.SearchRequest) (mode
 
 		if err == nil {
 			cachedResult.Metadata.CacheHit = true
-			return cachedResult, err
+			applySeatHoldsToResults(ctx, &cachedResult, req)
+			return cachedResult, utils.ApplyOfferTokens(cachedResult.Flights, req)
 		}
 	}
 
+	// expand city codes and airport lists before provider fan-out
+	req.OriginAirports, err = references.ExpandLocation(req.Origin)
+	if err != nil {
+		return models.SearchResponse{}, err
+	}
+
+	req.DestinationAirports, err = references.ExpandLocation(req.Destination)
+	if err != nil {
+		return models.SearchResponse{}, err
+	}
+
+	// widen to nearby airports when a radius is requested
+	req.OriginAlternatives = references.FindNearbyAirports(req.OriginAirports, req.OriginRadiusKm)
+	req.OriginAirports = append(req.OriginAirports, slices.Sorted(maps.Keys(req.OriginAlternatives))...)
+
+	req.DestinationAlternatives = references.FindNearbyAirports(req.DestinationAirports, req.DestinationRadiusKm)
+	req.DestinationAirports = append(req.DestinationAirports, slices.Sorted(maps.Keys(req.DestinationAlternatives))...)
+
 	var wg sync.WaitGroup
 	resultsCh := make(chan []models.Flight, len(s.providers))
 	errorsCh := make(chan error, len(s.providers))
@@ -71,20 +108,78 @@ func (s *SearchService) Search(ctx *gin.Context, req models.SearchRequest) (mode
 		flights = append(flights, f...)
 	}
 
+	// refundable flags from each fare's rules
+	utils.ApplyFareRuleSummaries(flights)
+
+	// rates are only fetched when a price or pricing rule needs converting
+	getRates := sync.OnceValues(func() (models.FxRates, error) {
+		return s.fxRates.GetRates(ctx)
+	})
+
+	// currency conversion, markups & commissions
+	if err := applySellingPrices(flights, req, getRates); err != nil {
+		return models.SearchResponse{}, err
+	}
+
+	// merge the same flight offered by several providers
+	duplicatesMerged := utils.DeduplicateFlights(&flights)
+
+	for i := range flights {
+		flights[i].RouteMatch = getRouteMatch(flights[i], req)
+	}
+
+	// price history is best effort and recorded in the background
+	NewPriceHistoryService().RecordAsync(flights)
+
+	// promo code discounts on top of selling prices
+	var promoStatus *models.PromoStatus
+	if req.PromoCode != "" {
+		promoStatus, err = s.applyPromoCode(ctx, flights, req, getRates)
+		if err != nil {
+			return models.SearchResponse{}, err
+		}
+	}
+
+	// fare plus a 20 kg bag, for best value scoring
+	if req.AllInPrice {
+		s.applyAllInPrices(ctx, flights, req, getRates)
+	}
+
 	// filter
 	utils.ApplySearchFilters(&flights, req)
 
 	// sorting, scoring
-	utils.ApplySearchSorter(flights, req.SortBy, req.SortOrder)
+	weights := utils.GetScoringWeights(req)
+	utils.ApplySearchSorter(flights, req.SortBy, weights)
+
+	if req.IncludeScore {
+		utils.ApplyFlightScores(flights, weights)
+	}
+
+	// pareto frontier, then highlights of the flights that are left
+	utils.MarkParetoOptimal(flights)
+	if req.Filters.ParetoOptimalOnly {
+		utils.KeepParetoOptimal(&flights)
+	}
+	highlights := utils.ApplySearchHighlights(flights, weights)
+
+	utils.LocalizeFlights(flights, req.Locale)
+
+	if !req.Internal {
+		utils.HidePricingBreakdowns(flights)
+	}
 
 	results := models.SearchResponse{
-		Criteria: req,
+		Criteria:   req,
+		Highlights: highlights,
 		Metadata: models.Metadata{
 			TotalResults:     len(flights),
 			ProvidersQueried: len(s.providers),
 			ProvidersSuccess: len(s.providers) - len(errorsCh),
 			ProvidersFailed:  len(errorsCh),
+			DuplicatesMerged: duplicatesMerged,
 			SearchTimeMs:     int(time.Since(start).Milliseconds()),
+			Promo:            promoStatus,
 		},
 		Flights: flights,
 	}
@@ -94,11 +189,190 @@ func (s *SearchService) Search(ctx *gin.Context, req models.SearchRequest) (mode
 		cache.Set(ctx, cacheKey, resultToCache, 5*time.Minute)
 	}
 
-	return results, nil
+	// holds change by the minute, so they are applied after caching too
+	applySeatHoldsToResults(ctx, &results, req)
+
+	// signed references to each result for revalidation and booking, issued
+	// per response so cached results never hand out stale tokens
+	return results, utils.ApplyOfferTokens(results.Flights, req)
+}
+
+// Reduce seats by the active holds and drop flights that no longer seat every
+// passenger, highlighting what is left again. Holds are skipped when Redis
+// fails, like the search cache.
+func applySeatHoldsToResults(ctx *gin.Context, results *models.SearchResponse, req models.SearchRequest) {
+	if err := applySeatHolds(ctx, results.Flights, ""); err != nil {
+		return
+	}
+
+	found := len(results.Flights)
+	results.Flights = slices.DeleteFunc(results.Flights, func(flight models.Flight) bool {
+		return flight.AvailableSeats < req.Passengers
+	})
+	results.Metadata.TotalResults = len(results.Flights)
+
+	if len(results.Flights) < found {
+		results.Highlights = utils.ApplySearchHighlights(results.Flights, utils.GetScoringWeights(req))
+	}
+}
+
+// Turn provider fares into selling prices in the requested currency, so pricing
+// rules, filters and sorting all work on the same amounts
+func applySellingPrices(
+	flights []models.Flight,
+	req models.SearchRequest,
+	getRates func() (models.FxRates, error),
+) error {
+	if utils.HasForeignPrices(flights, req.Currency) {
+		rates, err := getRates()
+		if err != nil {
+			return err
+		}
+
+		if err := utils.ConvertFlightPrices(flights, req.Currency, rates); err != nil {
+			return err
+		}
+	}
+
+	utils.ApplyPricingRules(flights, req, getRates)
+	return nil
+}
+
+// Extras a provider sells with an offer, priced in the requested currency.
+// Providers without extras return none.
+func (s *SearchService) getAncillaries(
+	ctx *gin.Context,
+	offer models.Offer,
+	currency string,
+	getRates func() (models.FxRates, error),
+) ([]models.Ancillary, error) {
+	provider, ok := s.getProvider(offer.Provider).(providers.AncillaryProvider)
+	if !ok {
+		return nil, nil
+	}
+
+	ancillaries, err := provider.GetAncillaries(ctx, offer)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range ancillaries {
+		if ancillaries[i].Price.Currency == currency {
+			continue
+		}
+
+		rates, err := getRates()
+		if err != nil {
+			return nil, err
+		}
+
+		ancillaries[i].Price, err = utils.ConvertPrice(ancillaries[i].Price, currency, rates)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ancillaries, nil
 }
 
+// A flight whose extras can't be listed or priced gets no all-in price, rather
+// than failing the search, and is scored as the dearest
+func (s *SearchService) applyAllInPrices(
+	ctx *gin.Context,
+	flights []models.Flight,
+	req models.SearchRequest,
+	getRates func() (models.FxRates, error),
+) {
+	for i := range flights {
+		ancillaries, err := s.getAncillaries(ctx, utils.NewOffer(flights[i], req), req.Currency, getRates)
+		if err != nil {
+			log.Printf("all-in price of flight %s: %v", flights[i].ID, err)
+			continue
+		}
+		utils.ApplyAllInPrice(&flights[i], ancillaries)
+	}
+}
+
+func (s *SearchService) applyPromoCode(
+	ctx *gin.Context,
+	flights []models.Flight,
+	req models.SearchRequest,
+	getRates func() (models.FxRates, error),
+) (*models.PromoStatus, error) {
+	promoStatus := &models.PromoStatus{Code: req.PromoCode}
+
+	promotion, status := NewPromoService().CheckPromoCode(ctx, req.PromoCode)
+	if status != "" {
+		promoStatus.Status = status
+		promoStatus.Message = getPromoStatusMessage(status)
+		return promoStatus, nil
+	}
+
+	eligible, err := utils.ApplyPromotion(flights, promotion, req, getRates)
+	if err != nil {
+		return nil, err
+	}
+
+	promoStatus.Status = utils.PromoStatusApplied
+	promoStatus.EligibleFlights = eligible
+	if eligible == 0 {
+		promoStatus.Status = utils.PromoStatusNotEligible
+	}
+	promoStatus.Message = getPromoStatusMessage(promoStatus.Status)
+
+	return promoStatus, nil
+}
+
+func getPromoStatusMessage(status string) string {
+	switch status {
+	case utils.PromoStatusApplied:
+		return "promo code applied to eligible flights"
+	case utils.PromoStatusInvalid:
+		return "promo code does not exist"
+	case utils.PromoStatusNotStarted:
+		return "promo code is not valid yet"
+	case utils.PromoStatusExpired:
+		return "promo code has expired"
+	case utils.PromoStatusUsageLimitReached:
+		return "promo code has been fully redeemed"
+	case utils.PromoStatusNotEligible:
+		return "no flight is eligible for this promo code"
+	case utils.PromoStatusUnavailable:
+		return "promo code could not be checked, try again later"
+	}
+	return ""
+}
+
+func getRouteMatch(flight models.Flight, req models.SearchRequest) *models.RouteMatch {
+	routeMatch := &models.RouteMatch{
+		RequestedOrigin:      req.Origin,
+		MatchedOrigin:        flight.Departure.Airport,
+		RequestedDestination: req.Destination,
+		MatchedDestination:   flight.Arrival.Airport,
+	}
+
+	if distance, ok := req.OriginAlternatives[flight.Departure.Airport]; ok {
+		distance = math.Round(distance*10) / 10
+		routeMatch.AlternativeAirport = true
+		routeMatch.OriginDistanceKm = &distance
+	}
+
+	if distance, ok := req.DestinationAlternatives[flight.Arrival.Airport]; ok {
+		distance = math.Round(distance*10) / 10
+		routeMatch.AlternativeAirport = true
+		routeMatch.DestinationDistanceKm = &distance
+	}
+
+	return routeMatch
+}
+
+// Internal callers get pricing breakdowns, so their results are cached apart
 func getCacheKeyFromSearchRequest(req models.SearchRequest) string {
 	reqJson, _ := json.Marshal(req)
 	hash := sha256.Sum256(reqJson)
+
+	if req.Internal {
+		return fmt.Sprintf("Q:INTERNAL:%x", hash)
+	}
 	return fmt.Sprintf("Q:%x", hash)
 }
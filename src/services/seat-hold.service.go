@@ -0,0 +1,376 @@
+package services
+
+import (
+	"bookcabin-app-go/src/libs"
+	"bookcabin-app-go/src/models"
+	"bookcabin-app-go/src/providers"
+	"bookcabin-app-go/src/utils"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	SeatHoldEventCreated   = "hold.created"
+	SeatHoldEventReleased  = "hold.released"
+	SeatHoldEventExpired   = "hold.expired"
+	SeatHoldEventConverted = "hold.converted"
+)
+
+/*
+Seat holds in Redis:
+  - HOLD:<id>                   the hold, expiring with it
+  - HOLDS:EXPIRY                sorted set of hold IDs by expiry, scanned by the reaper
+  - HOLDS:DATA                  hash of every unreleased hold, still readable after HOLD:<id> expired
+  - HOLDS:FLIGHT:<flight key>   sorted set of "<id>|<passengers>" by expiry, summed by searches
+*/
+const (
+	seatHoldExpiryKey = "HOLDS:EXPIRY"
+	seatHoldDataKey   = "HOLDS:DATA"
+
+	// attempts to place a hold while other holds on the flight keep changing
+	maxSeatHoldAttempts = 5
+)
+
+var (
+	ErrSeatHoldNotFound  = errors.New("seat hold not found")
+	ErrOfferAlreadyHeld  = errors.New("offer is already held")
+	ErrSeatHoldsDisabled = errors.New("provider does not hold seats")
+)
+
+type SeatHoldService struct {
+	offers *OfferService
+}
+
+func NewSeatHoldService() *SeatHoldService {
+	return &SeatHoldService{offers: NewOfferService()}
+}
+
+func getSeatHoldTTL() time.Duration {
+	ttlInSeconds, _ := strconv.Atoi(libs.GetEnv("SEAT_HOLD_TTL_IN_S", "900"))
+	return time.Duration(ttlInSeconds) * time.Second
+}
+
+// Hold seats for the passengers of a verified offer. The offer is revalidated
+// first, so only an available offer at its current fare is held. The returned
+// hold carries a new offer token that books the held seats.
+func (s *SeatHoldService) Hold(ctx *gin.Context, offer models.Offer, locale string) (models.SeatHold, error) {
+	if offer.HoldID != "" {
+		return models.SeatHold{}, fmt.Errorf("%w by %s", ErrOfferAlreadyHeld, offer.HoldID)
+	}
+
+	provider := s.getSeatHoldProvider(offer.Provider)
+	if provider == nil {
+		return models.SeatHold{}, fmt.Errorf("%w: %s", ErrSeatHoldsDisabled, offer.Provider)
+	}
+
+	revalidated, providerSeats, err := s.offers.revalidate(ctx, offer.FlightID, offer, locale, false)
+	if err != nil {
+		return models.SeatHold{}, err
+	}
+
+	if !revalidated.Available {
+		return models.SeatHold{}, ErrOfferUnavailable
+	}
+
+	if revalidated.PriceStatus != PriceStatusUnchanged {
+		return models.SeatHold{}, fmt.Errorf("%w from %s to %s, revalidate to get the new offer token",
+			ErrPriceChanged, revalidated.PreviousPrice.Formatted, revalidated.CurrentPrice.Formatted)
+	}
+
+	reference, err := provider.HoldSeats(ctx, revalidated.Flight, offer.Passengers)
+	if errors.Is(err, providers.ErrNotEnoughSeats) {
+		return models.SeatHold{}, ErrOfferUnavailable
+	}
+	if err != nil {
+		return models.SeatHold{}, err
+	}
+
+	idBytes := make([]byte, 5)
+	if _, err := rand.Read(idBytes); err != nil {
+		provider.ReleaseHold(ctx, reference)
+		return models.SeatHold{}, err
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(getSeatHoldTTL())
+
+	hold := models.SeatHold{
+		ID:                 fmt.Sprintf("HD%X", idBytes),
+		Provider:           offer.Provider,
+		ProviderReference:  reference,
+		FlightID:           offer.FlightID,
+		FlightNumber:       offer.FlightNumber,
+		DepartureTimestamp: revalidated.Flight.Departure.Timestamp,
+		Passengers:         offer.Passengers,
+		CreatedAt:          now.Format(time.RFC3339),
+		ExpiresAt:          expiresAt.Format(time.RFC3339),
+	}
+
+	if err := saveSeatHold(ctx, hold, expiresAt, providerSeats); err != nil {
+		provider.ReleaseHold(ctx, reference)
+		return models.SeatHold{}, err
+	}
+
+	heldOffer := offer
+	heldOffer.HoldID = hold.ID
+	hold.OfferToken, _, err = utils.IssueOfferToken(heldOffer)
+	if err != nil {
+		return hold, err
+	}
+
+	publishSeatHoldEvent(ctx, SeatHoldEventCreated, hold)
+	return hold, nil
+}
+
+func (s *SeatHoldService) getSeatHoldProvider(name string) providers.SeatHoldProvider {
+	if provider, ok := s.offers.search.getProvider(name).(providers.SeatHoldProvider); ok {
+		return provider
+	}
+	return nil
+}
+
+// Holds are only found until they expire
+func (s *SeatHoldService) Get(ctx context.Context, id string) (models.SeatHold, error) {
+	var hold models.SeatHold
+
+	data, err := libs.GetCacheClientInstance().Get(ctx, getSeatHoldKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return hold, ErrSeatHoldNotFound
+	}
+	if err != nil {
+		return hold, err
+	}
+
+	err = json.Unmarshal(data, &hold)
+	return hold, err
+}
+
+// Give the held seats back, e.g. when the traveller abandons checkout
+func (s *SeatHoldService) Release(ctx context.Context, id string) (models.SeatHold, error) {
+	return s.release(ctx, id, SeatHoldEventReleased)
+}
+
+// Release every expired hold with its provider, see RunSeatHoldReaper
+func (s *SeatHoldService) ReleaseExpired(ctx context.Context) error {
+	ids, err := libs.GetCacheClientInstance().ZRangeByScore(ctx, seatHoldExpiryKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(time.Now().Unix(), 10),
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, id := range ids {
+		// another instance may have released it first
+		if _, err := s.release(ctx, id, SeatHoldEventExpired); err != nil && !errors.Is(err, ErrSeatHoldNotFound) {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Release a hold once. Removing it from HOLDS:EXPIRY claims it, so of several
+// instances reaping at once only one calls the provider and emits the event.
+func (s *SeatHoldService) release(ctx context.Context, id string, event string) (models.SeatHold, error) {
+	var hold models.SeatHold
+	cache := libs.GetCacheClientInstance()
+
+	data, err := cache.HGet(ctx, seatHoldDataKey, id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return hold, ErrSeatHoldNotFound
+	}
+	if err != nil {
+		return hold, err
+	}
+
+	if err := json.Unmarshal(data, &hold); err != nil {
+		return hold, err
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, hold.ExpiresAt)
+	if err != nil {
+		return hold, err
+	}
+
+	claimed, err := cache.ZRem(ctx, seatHoldExpiryKey, id).Result()
+	if err != nil {
+		return hold, err
+	}
+	if claimed == 0 {
+		return hold, ErrSeatHoldNotFound
+	}
+
+	if provider := s.getSeatHoldProvider(hold.Provider); provider != nil {
+		if err := provider.ReleaseHold(ctx, hold.ProviderReference); err != nil {
+			// put it back, so the reaper tries again
+			cache.ZAdd(ctx, seatHoldExpiryKey, redis.Z{Score: float64(expiresAt.Unix()), Member: id})
+			return hold, err
+		}
+	}
+
+	_, err = cache.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, getSeatHoldFlightKey(hold.Provider, hold.FlightID, hold.DepartureTimestamp), getSeatHoldMember(hold))
+		pipe.Del(ctx, getSeatHoldKey(id))
+		pipe.HDel(ctx, seatHoldDataKey, id)
+		return nil
+	})
+	if err != nil {
+		return hold, err
+	}
+
+	publishSeatHoldEvent(ctx, event, hold)
+	return hold, nil
+}
+
+// Release expired holds every SEAT_HOLD_REAPER_INTERVAL_IN_S seconds until ctx is done
+func (s *SeatHoldService) RunSeatHoldReaper(ctx context.Context) {
+	intervalInSeconds, _ := strconv.Atoi(libs.GetEnv("SEAT_HOLD_REAPER_INTERVAL_IN_S", "30"))
+	ticker := time.NewTicker(time.Duration(max(intervalInSeconds, 1)) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.ReleaseExpired(ctx)
+		}
+	}
+}
+
+func getSeatHoldKey(id string) string {
+	return "HOLD:" + id
+}
+
+func getSeatHoldFlightKey(provider string, flightId string, departureTimestamp int64) string {
+	return fmt.Sprintf("HOLDS:FLIGHT:%s:%s:%d", provider, flightId, departureTimestamp)
+}
+
+func getSeatHoldMember(hold models.SeatHold) string {
+	return fmt.Sprintf("%s|%d", hold.ID, hold.Passengers)
+}
+
+// Store the hold if the provider's seats still cover it together with every
+// other active hold on the flight. The flight's holds are watched while checking,
+// so of two holds racing for the last seats only one is stored.
+func saveSeatHold(ctx context.Context, hold models.SeatHold, expiresAt time.Time, providerSeats int) error {
+	data, err := json.Marshal(hold)
+	if err != nil {
+		return err
+	}
+
+	cache := libs.GetCacheClientInstance()
+	flightKey := getSeatHoldFlightKey(hold.Provider, hold.FlightID, hold.DepartureTimestamp)
+	score := float64(expiresAt.Unix())
+
+	for range maxSeatHoldAttempts {
+		err = cache.Watch(ctx, func(tx *redis.Tx) error {
+			members, err := tx.ZRangeByScore(ctx, flightKey, getActiveSeatHoldRange()).Result()
+			if err != nil {
+				return err
+			}
+
+			if sumHeldSeats(members, "")+hold.Passengers > providerSeats {
+				return ErrOfferUnavailable
+			}
+
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Set(ctx, getSeatHoldKey(hold.ID), data, time.Until(expiresAt))
+				pipe.HSet(ctx, seatHoldDataKey, hold.ID, data)
+				pipe.ZAdd(ctx, seatHoldExpiryKey, redis.Z{Score: score, Member: hold.ID})
+				pipe.ZAdd(ctx, flightKey, redis.Z{Score: score, Member: getSeatHoldMember(hold)})
+				return nil
+			})
+			return err
+		}, flightKey)
+
+		// another hold on the flight changed while checking, check again
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// Holds on a flight that have not expired yet
+func getActiveSeatHoldRange() *redis.ZRangeBy {
+	return &redis.ZRangeBy{
+		Min: "(" + strconv.FormatInt(time.Now().Unix(), 10),
+		Max: "+inf",
+	}
+}
+
+// Seats held by the "<id>|<passengers>" members, leaving out one hold
+func sumHeldSeats(members []string, excludeHoldId string) int {
+	held := 0
+	for _, member := range members {
+		id, passengers, _ := strings.Cut(member, "|")
+		if id == excludeHoldId {
+			continue
+		}
+
+		seats, _ := strconv.Atoi(passengers)
+		held += seats
+	}
+	return held
+}
+
+// Announce hold changes on SEAT_HOLD_EVENTS_CHANNEL, best effort
+func publishSeatHoldEvent(ctx context.Context, eventType string, hold models.SeatHold) {
+	hold.OfferToken = ""
+
+	data, err := json.Marshal(models.SeatHoldEvent{
+		Type: eventType,
+		Hold: hold,
+		At:   time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return
+	}
+
+	channel := libs.GetEnv("SEAT_HOLD_EVENTS_CHANNEL", "seat-holds")
+	libs.GetCacheClientInstance().Publish(ctx, channel, data)
+}
+
+// Take seats held by others off each flight's AvailableSeats. A hold counts
+// until it expires, even before the reaper has released it.
+func applySeatHolds(ctx context.Context, flights []models.Flight, excludeHoldId string) error {
+	if len(flights) == 0 {
+		return nil
+	}
+
+	activeRange := getActiveSeatHoldRange()
+
+	cmds, err := libs.GetCacheClientInstance().Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, flight := range flights {
+			pipe.ZRangeByScore(ctx, getSeatHoldFlightKey(flight.Provider, flight.ID, flight.Departure.Timestamp), activeRange)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, cmd := range cmds {
+		members, err := cmd.(*redis.StringSliceCmd).Result()
+		if err != nil {
+			return err
+		}
+
+		flights[i].AvailableSeats = max(flights[i].AvailableSeats-sumHeldSeats(members, excludeHoldId), 0)
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"bookcabin-app-go/src/models"
+	"regexp"
+	"strconv"
+)
+
+/* Baggage allowances out of free text such as "7 kg", "20kg checked" or "2 pcs x 23 kg" */
+var (
+	baggageWeightPattern = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*kgs?\b`)
+	baggagePiecesPattern = regexp.MustCompile(`(?i)(\d+)\s*(?:pcs?|pieces?)\b`)
+)
+
+// Allowance in the text, nil when it names no positive weight or piece count
+func ParseBaggageAllowance(text string) *models.BaggageAllowance {
+	var allowance models.BaggageAllowance
+
+	if match := baggageWeightPattern.FindStringSubmatch(text); match != nil {
+		if weight, err := strconv.ParseFloat(match[1], 64); err == nil && weight > 0 {
+			allowance.WeightKg = &weight
+		}
+	}
+
+	if match := baggagePiecesPattern.FindStringSubmatch(text); match != nil {
+		if pieces, err := strconv.Atoi(match[1]); err == nil && pieces > 0 {
+			allowance.Pieces = &pieces
+		}
+	}
+
+	if allowance.WeightKg == nil && allowance.Pieces == nil {
+		return nil
+	}
+	return &allowance
+}
+
+// Allowance of a number of pieces, nil for none
+func NewBaggagePieces(pieces int) *models.BaggageAllowance {
+	if pieces <= 0 {
+		return nil
+	}
+	return &models.BaggageAllowance{Pieces: &pieces}
+}
+
+func NewBaggage(carryOn *models.BaggageAllowance, checked *models.BaggageAllowance, raw string) models.Baggage {
+	return models.Baggage{
+		CarryOn:         carryOn,
+		Checked:         checked,
+		CheckedIncluded: checked != nil,
+		Raw:             raw,
+	}
+}
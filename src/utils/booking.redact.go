@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"bookcabin-app-go/src/models"
+	"strings"
+)
+
+const documentNumberVisibleDigits = 3
+
+// Keep booking secrets out of responses. The access token hash is never
+// returned, and public callers only see the last digits of travel documents.
+func RedactBooking(booking *models.Booking, internal bool) {
+	booking.AccessTokenHash = ""
+	if internal {
+		return
+	}
+
+	passengers := make([]models.BookingPassenger, len(booking.Passengers))
+	for i, passenger := range booking.Passengers {
+		passenger.DocumentNumber = maskDocumentNumber(passenger.DocumentNumber)
+		passengers[i] = passenger
+	}
+	booking.Passengers = passengers
+}
+
+// "A1234567" -> "*****567"
+func maskDocumentNumber(documentNumber string) string {
+	if len(documentNumber) <= documentNumberVisibleDigits {
+		return strings.Repeat("*", len(documentNumber))
+	}
+
+	hidden := len(documentNumber) - documentNumberVisibleDigits
+	return strings.Repeat("*", hidden) + documentNumber[hidden:]
+}
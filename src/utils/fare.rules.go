@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"bookcabin-app-go/src/libs"
+	"bookcabin-app-go/src/models"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+/* Fare rules: refunds, changes, no-shows and baggage per airline and fare type */
+const (
+	FareRuleSourceFareType = "fare_type"
+	FareRuleSourceAirline  = "airline"
+	FareRuleSourceDefault  = "default"
+)
+
+// Rules are loaded once from config, a file that can't be read or parsed is an error
+var loadFareRules = sync.OnceValues(func() (models.FareRulesConfig, error) {
+	var config models.FareRulesConfig
+	file := libs.GetEnv("FARE_RULES_FILE", "fare_rules.json")
+
+	data, err := libs.ReadConfigFile(file)
+	if err != nil {
+		return config, fmt.Errorf("fare rules: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("fare rules %s: %w", file, err)
+	}
+	return config, nil
+})
+
+// Checked at startup, so missing or malformed fare rules stop the app instead
+// of making every fare non-refundable
+func CheckFareRules() error {
+	_, err := loadFareRules()
+	return err
+}
+
+// A fare type the airline lists has its own rule, other fares use the
+// airline's rule, and airlines without one the default rule. The rules are
+// checked at startup, so failing to load them here is a programming error.
+func GetFareRule(airlineCode string, fareType string) (models.FareRule, string) {
+	fareRules, err := loadFareRules()
+	if err != nil {
+		panic(err)
+	}
+
+	airlineRules, ok := fareRules.Airlines[airlineCode]
+	if !ok {
+		return fareRules.Default, FareRuleSourceDefault
+	}
+
+	if rule, ok := airlineRules.FareTypes[strings.ToUpper(fareType)]; ok && fareType != "" {
+		return rule, FareRuleSourceFareType
+	}
+	return airlineRules.FareRule, FareRuleSourceAirline
+}
+
+// Mark each flight refundable or not, so refundable fares can be filtered
+func ApplyFareRuleSummaries(flights []models.Flight) {
+	for i := range flights {
+		rule, _ := GetFareRule(flights[i].Airline.Code, flights[i].FareType)
+		flights[i].Refundable = rule.Refundable
+	}
+}
+
+// Fee on an amount paid by the passengers: a percentage of it plus the fixed
+// amount per passenger, converted from the fee's currency and never above it
+func CalculateFareFee(
+	fee models.FareFee,
+	paid float64,
+	passengers int,
+	currency string,
+	getRates func() (models.FxRates, error),
+) (float64, error) {
+	fixedAmount := fee.Amount * float64(passengers)
+
+	if feeCurrency := cmp.Or(fee.Currency, currency); fixedAmount != 0 && feeCurrency != currency {
+		rates, err := getRates()
+		if err != nil {
+			return 0, err
+		}
+
+		rate, err := rates.Rate(feeCurrency, currency)
+		if err != nil {
+			return 0, err
+		}
+		fixedAmount *= rate
+	}
+
+	return RoundAmount(min(paid*fee.Percent/100+fixedAmount, paid), currency), nil
+}
+
+// Refund for cancelling the whole booking under its fare rule, never below zero
+func CalculateRefund(
+	booking models.Booking,
+	rule models.FareRule,
+	getRates func() (models.FxRates, error),
+) (models.BookingRefund, error) {
+	paid := booking.TotalPrice
+	currency := paid.Currency
+
+	if !rule.Refundable {
+		return newBookingRefund(false, paid.Amount, 0, currency, booking.Locale), nil
+	}
+
+	fee, err := CalculateFareFee(rule.CancellationFee, paid.Amount, len(booking.Passengers), currency, getRates)
+	if err != nil {
+		return models.BookingRefund{}, err
+	}
+
+	return newBookingRefund(true, fee, RoundAmount(paid.Amount-fee, currency), currency, booking.Locale), nil
+}
+
+func newBookingRefund(refundable bool, fee float64, amount float64, currency string, locale string) models.BookingRefund {
+	return models.BookingRefund{
+		Refundable: refundable,
+		CancellationFee: models.Price{
+			Amount:    fee,
+			Currency:  currency,
+			Formatted: FormatLocalizedPrice(fee, currency, locale),
+		},
+		Amount: models.Price{
+			Amount:    amount,
+			Currency:  currency,
+			Formatted: FormatLocalizedPrice(amount, currency, locale),
+		},
+	}
+}
@@ -2,9 +2,9 @@ package utils
 
 import (
 	"bookcabin-app-go/src/constants"
+	"bookcabin-app-go/src/references"
 	"fmt"
 	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
@@ -30,26 +30,58 @@ func FormatDurationToHumans(durationInMin int) string {
 	return fmt.Sprintf("%02dh %02dm", h, m)
 }
 
+// Parse provider durations such as "1h 45m" or "55m" into minutes
 func FormatDurationToMinutes(duration string) int {
-	parts := strings.Fields(duration)
-	if len(parts) < 2 {
+	d, err := time.ParseDuration(strings.ReplaceAll(duration, " ", ""))
+	if err != nil {
 		return 0
 	}
 
-	hours, _ := strconv.Atoi(strings.TrimSuffix(parts[0], "h"))
-	minutes, _ := strconv.Atoi(strings.TrimSuffix(parts[1], "m"))
-
-	return hours*60 + minutes
+	return int(d.Minutes())
 }
 
-func FormatPrice(price int, currency string) string {
+func FormatPrice(price float64, currency string) string {
 	p := message.NewPrinter(language.Indonesian)
-	formattedPrice := p.Sprintf("%d", price)
+	formattedPrice := p.Sprintf("%.*f", GetCurrencyDecimals(currency), price)
 	return currency + " " + formattedPrice
 }
 
+func GetCurrencyDecimals(currency string) int {
+	if decimals, ok := constants.CurrencyDecimals[currency]; ok {
+		return decimals
+	}
+	return 2
+}
+
+// Format in the airport's local time, keeping the provider's offset for unknown airports
 func FormatDateTime(dateTime time.Time, airportCode string) string {
-	tz, _ := time.LoadLocation(constants.Timezones[airportCode])
-	formattedDateTime := dateTime.In(tz).Format(constants.GA_DateTimeLayout)
-	return formattedDateTime
+	if tz := references.GetAirportLocation(airportCode); tz != nil {
+		dateTime = dateTime.In(tz)
+	}
+	return dateTime.Format(constants.GA_DateTimeLayout)
+}
+
+// Number of single character edits needed to turn one string into the other
+func LevenshteinDistance(a string, b string) int {
+	source, target := []rune(a), []rune(b)
+	previous := make([]int, len(target)+1)
+	current := make([]int, len(target)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(source); i++ {
+		current[0] = i
+		for j := 1; j <= len(target); j++ {
+			substitution := previous[j-1]
+			if source[i-1] != target[j-1] {
+				substitution++
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, substitution)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(target)]
 }
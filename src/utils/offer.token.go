@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"bookcabin-app-go/src/constants"
+	"bookcabin-app-go/src/libs"
+	"bookcabin-app-go/src/models"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/* Offer tokens: base64url JSON claims and their HMAC-SHA256 signature, joined by a dot */
+var (
+	ErrInvalidOfferToken  = errors.New("offer token is malformed or its signature does not match")
+	ErrExpiredOfferToken  = errors.New("offer token has expired")
+	ErrNoOfferTokenSecret = errors.New("OFFER_TOKEN_SECRET is not set, offer tokens can't be signed")
+)
+
+var (
+	offerTokenSecret     []byte
+	offerTokenSecretOnce sync.Once
+)
+
+// Secret from OFFER_TOKEN_SECRET, shared by every replica so tokens hold across
+// restarts and instances
+func getOfferTokenSecret() ([]byte, error) {
+	offerTokenSecretOnce.Do(func() {
+		offerTokenSecret = []byte(libs.GetEnv("OFFER_TOKEN_SECRET", ""))
+	})
+
+	if len(offerTokenSecret) == 0 {
+		return nil, ErrNoOfferTokenSecret
+	}
+	return offerTokenSecret, nil
+}
+
+// Checked at startup, so a missing secret stops the app instead of failing
+// every search
+func CheckOfferTokenSecret() error {
+	_, err := getOfferTokenSecret()
+	return err
+}
+
+func GetOfferTokenTTL() time.Duration {
+	ttlInSeconds, _ := strconv.Atoi(libs.GetEnv("OFFER_TOKEN_TTL_IN_S", "600"))
+	return time.Duration(ttlInSeconds) * time.Second
+}
+
+// Claims for a priced flight, the fare being what each passenger pays
+func NewOffer(flight models.Flight, req models.SearchRequest) models.Offer {
+	departureDate := req.DepartureDate
+	if departure, err := time.Parse(constants.GA_DateTimeLayout, flight.Departure.DateTime); err == nil {
+		departureDate = departure.Format(time.DateOnly)
+	}
+
+	offer := models.Offer{
+		Provider:      flight.Provider,
+		FlightID:      flight.ID,
+		FlightNumber:  flight.FlightNumber,
+		Airline:       flight.Airline.Code,
+		FareType:      flight.FareType,
+		Origin:        flight.Departure.Airport,
+		Destination:   flight.Arrival.Airport,
+		DepartureDate: departureDate,
+		Passengers:    req.Passengers,
+		CabinClass:    req.CabinClass,
+		Channel:       req.Channel,
+		Fare:          GetEffectivePrice(flight),
+		Currency:      flight.Price.Currency,
+	}
+
+	if flight.Discount != nil {
+		offer.PromoCode = flight.Discount.Code
+	}
+
+	return offer
+}
+
+// Sign the offer, valid for OFFER_TOKEN_TTL_IN_S seconds from now
+func IssueOfferToken(offer models.Offer) (string, int64, error) {
+	offer.ExpiresAt = time.Now().Add(GetOfferTokenTTL()).Unix()
+
+	claims, err := json.Marshal(offer)
+	if err != nil {
+		return "", 0, err
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(claims)
+	signature, err := signOfferPayload(payload)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return payload + "." + signature, offer.ExpiresAt, nil
+}
+
+// Check the signature and expiry of a token and return the offer it vouches for
+func ParseOfferToken(token string) (models.Offer, error) {
+	var offer models.Offer
+
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return offer, ErrInvalidOfferToken
+	}
+
+	expectedSignature, err := signOfferPayload(payload)
+	if err != nil {
+		return offer, err
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(expectedSignature)) {
+		return offer, ErrInvalidOfferToken
+	}
+
+	claims, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return offer, ErrInvalidOfferToken
+	}
+
+	if err := json.Unmarshal(claims, &offer); err != nil {
+		return offer, ErrInvalidOfferToken
+	}
+
+	if time.Now().Unix() > offer.ExpiresAt {
+		return offer, ErrExpiredOfferToken
+	}
+
+	return offer, nil
+}
+
+func signOfferPayload(payload string) (string, error) {
+	secret, err := getOfferTokenSecret()
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Give every flight a token for its current price, so later steps can trust it
+func ApplyOfferTokens(flights []models.Flight, req models.SearchRequest) error {
+	for i := range flights {
+		token, _, err := IssueOfferToken(NewOffer(flights[i], req))
+		if err != nil {
+			return err
+		}
+		flights[i].OfferToken = token
+	}
+
+	return nil
+}
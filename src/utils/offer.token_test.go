@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"bookcabin-app-go/src/models"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	os.Setenv("OFFER_TOKEN_SECRET", "offer-token-test-secret")
+	os.Exit(m.Run())
+}
+
+// Sign claims as they are, so tests can issue tokens that already expired
+func signTestOffer(t *testing.T, offer models.Offer) string {
+	t.Helper()
+
+	claims, err := json.Marshal(offer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(claims)
+	return payload + "." + signTestPayload(t, payload)
+}
+
+func signTestPayload(t *testing.T, payload string) string {
+	t.Helper()
+
+	signature, err := signOfferPayload(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signature
+}
+
+func TestParseOfferToken(t *testing.T) {
+	offer := models.Offer{
+		Provider:   "Garuda Indonesia",
+		FlightID:   "GA400_GarudaIndonesia",
+		Passengers: 1,
+		Fare:       1250000,
+		Currency:   "IDR",
+	}
+
+	validToken, _, err := IssueOfferToken(offer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, signature, _ := strings.Cut(validToken, ".")
+
+	tamperedOffer := offer
+	tamperedOffer.Fare = 1
+	tamperedOffer.ExpiresAt = time.Now().Add(time.Hour).Unix()
+	tamperedClaims, _ := json.Marshal(tamperedOffer)
+	tamperedPayload := base64.RawURLEncoding.EncodeToString(tamperedClaims)
+
+	expiredOffer := offer
+	expiredOffer.ExpiresAt = time.Now().Add(-time.Minute).Unix()
+
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{"valid token", validToken, nil},
+		{"tampered payload", tamperedPayload + "." + signature, ErrInvalidOfferToken},
+		{"wrong signature", payload + "." + base64.RawURLEncoding.EncodeToString([]byte("not-the-signature")), ErrInvalidOfferToken},
+		{"missing signature", payload, ErrInvalidOfferToken},
+		{"signed payload that isn't JSON", "bm90LWpzb24." + signTestPayload(t, "bm90LWpzb24"), ErrInvalidOfferToken},
+		{"expired token", signTestOffer(t, expiredOffer), ErrExpiredOfferToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := ParseOfferToken(tt.token)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseOfferToken() error = %v, want %v", err, tt.err)
+			}
+
+			if tt.err == nil && (parsed.FlightID != offer.FlightID || parsed.Fare != offer.Fare) {
+				t.Fatalf("ParseOfferToken() = %+v, want the issued offer", parsed)
+			}
+		})
+	}
+}
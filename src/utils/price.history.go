@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"bookcabin-app-go/src/models"
+	"cmp"
+	"math"
+	"slices"
+	"time"
+)
+
+/* Daily price statistics and how today's prices compare with them */
+const (
+	PriceLevelLow     = "low"
+	PriceLevelTypical = "typical"
+	PriceLevelHigh    = "high"
+
+	// days observed before the latest one, needed to call its prices low or high
+	minPriceLevelDays = 3
+)
+
+// Min, average and max per day the prices were observed, oldest day first.
+// Days follow the given location, e.g. the origin airport's timezone.
+func SummarizePriceHistory(observations []models.PriceObservation, currency string, loc *time.Location) []models.PriceHistoryDay {
+	byDate := make(map[string][]float64)
+	for _, observation := range observations {
+		date := time.Unix(observation.ObservedAt, 0).In(loc).Format(time.DateOnly)
+		byDate[date] = append(byDate[date], observation.Amount)
+	}
+
+	days := make([]models.PriceHistoryDay, 0, len(byDate))
+	for date, amounts := range byDate {
+		var total float64
+		for _, amount := range amounts {
+			total += amount
+		}
+
+		days = append(days, models.PriceHistoryDay{
+			Date:         date,
+			Min:          slices.Min(amounts),
+			Avg:          RoundAmount(total/float64(len(amounts)), currency),
+			Max:          slices.Max(amounts),
+			Observations: len(amounts),
+		})
+	}
+
+	slices.SortFunc(days, func(a, b models.PriceHistoryDay) int {
+		return cmp.Compare(a.Date, b.Date)
+	})
+
+	return days
+}
+
+// Compare the latest day's lowest price with the lowest prices of the days
+// before: in their bottom quarter is low, in their top quarter high. Empty
+// while fewer than minPriceLevelDays earlier days were observed.
+func GetPriceLevel(days []models.PriceHistoryDay) string {
+	if len(days) <= minPriceLevelDays {
+		return ""
+	}
+
+	latest := days[len(days)-1].Min
+
+	previous := make([]float64, 0, len(days)-1)
+	for _, day := range days[:len(days)-1] {
+		previous = append(previous, day.Min)
+	}
+	slices.Sort(previous)
+
+	switch {
+	case latest <= percentile(previous, 25):
+		return PriceLevelLow
+	case latest >= percentile(previous, 75):
+		return PriceLevelHigh
+	}
+	return PriceLevelTypical
+}
+
+// Linear interpolation between the closest ranks of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lower, upper := int(math.Floor(rank)), int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
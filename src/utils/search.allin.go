@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"bookcabin-app-go/src/models"
+)
+
+/* All-in prices: the fare plus the checked baggage needed for a 20 kg bag */
+const AllInBaggageKg = 20
+
+// Set the all-in price from the flight's extras, already priced in its currency.
+// Flights that include a bag of at least 20 kg, or a piece without a stated
+// weight, need nothing extra. Others add the cheapest baggage option covering
+// the missing weight, and are left without an all-in price when none does.
+func ApplyAllInPrice(flight *models.Flight, ancillaries []models.Ancillary) {
+	price := GetEffectivePrice(*flight)
+	currency := flight.Price.Currency
+
+	if missingKg := getMissingBaggageKg(flight.Baggage); missingKg > 0 {
+		bag, ok := getCheapestBaggage(ancillaries, missingKg)
+		if !ok {
+			flight.AllInPrice = nil
+			return
+		}
+		price += bag.Price.Amount
+	}
+
+	price = RoundAmount(price, currency)
+	flight.AllInPrice = &models.Price{
+		Amount:    price,
+		Currency:  currency,
+		Formatted: FormatPrice(price, currency),
+	}
+}
+
+func getMissingBaggageKg(baggage models.Baggage) float64 {
+	if !baggage.CheckedIncluded {
+		return AllInBaggageKg
+	}
+
+	if baggage.Checked.WeightKg == nil {
+		return 0
+	}
+	return max(AllInBaggageKg-*baggage.Checked.WeightKg, 0)
+}
+
+func getCheapestBaggage(ancillaries []models.Ancillary, minWeightKg float64) (models.Ancillary, bool) {
+	var cheapest models.Ancillary
+	found := false
+
+	for _, ancillary := range ancillaries {
+		if ancillary.WeightKg == nil || *ancillary.WeightKg < minWeightKg {
+			continue
+		}
+
+		if !found || ancillary.Price.Amount < cheapest.Price.Amount {
+			cheapest, found = ancillary, true
+		}
+	}
+
+	return cheapest, found
+}
+
+// Price best value scoring compares: the all-in price when requested, so
+// low-cost fares without a bag compare fairly with full-service ones
+func GetScoringPrice(flight models.Flight) float64 {
+	if flight.AllInPrice != nil {
+		return flight.AllInPrice.Amount
+	}
+	return GetEffectivePrice(flight)
+}
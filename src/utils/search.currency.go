@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"bookcabin-app-go/src/models"
+	"math"
+)
+
+func HasForeignPrices(flights []models.Flight, currency string) bool {
+	for _, flight := range flights {
+		if flight.Price.Currency != currency {
+			return true
+		}
+
+		for _, offer := range flight.AlternativeOffers {
+			if offer.Price.Currency != currency {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Convert every flight price, including alternative offers, into the requested currency
+func ConvertFlightPrices(flights []models.Flight, currency string, rates models.FxRates) error {
+	for i := range flights {
+		price, err := ConvertPrice(flights[i].Price, currency, rates)
+		if err != nil {
+			return err
+		}
+		flights[i].Price = price
+
+		for j := range flights[i].AlternativeOffers {
+			price, err := ConvertPrice(flights[i].AlternativeOffers[j].Price, currency, rates)
+			if err != nil {
+				return err
+			}
+			flights[i].AlternativeOffers[j].Price = price
+		}
+	}
+
+	return nil
+}
+
+// Convert a price, keeping the original amount and the applied rate
+func ConvertPrice(price models.Price, currency string, rates models.FxRates) (models.Price, error) {
+	if price.Currency == currency {
+		return price, nil
+	}
+
+	rate, err := rates.Rate(price.Currency, currency)
+	if err != nil {
+		return price, err
+	}
+
+	amount := RoundAmount(price.Amount*rate, currency)
+
+	return models.Price{
+		Amount:    amount,
+		Currency:  currency,
+		Formatted: FormatPrice(amount, currency),
+		Original: &models.OriginalPrice{
+			Amount:   price.Amount,
+			Currency: price.Currency,
+			Rate:     rate,
+		},
+	}, nil
+}
+
+// Round an amount to the currency's minor unit, e.g. whole rupiah or cents
+func RoundAmount(amount float64, currency string) float64 {
+	scale := math.Pow10(GetCurrencyDecimals(currency))
+	return math.Round(amount*scale) / scale
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"bookcabin-app-go/src/models"
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Merge offers for the same physical flight sold by more than one provider.
+// The cheapest offer becomes the result, the others are attached to it as
+// alternative offers. Returns the number of offers merged away.
+func DeduplicateFlights(flights *[]models.Flight) int {
+	groups := make(map[string][]models.Flight)
+	var keys []string
+
+	for _, flight := range *flights {
+		key := GetOperatingFlightKey(flight)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], flight)
+	}
+
+	dedupedFlights := make([]models.Flight, 0, len(keys))
+
+	for _, key := range keys {
+		offers := groups[key]
+
+		slices.SortStableFunc(offers, func(a, b models.Flight) int {
+			return cmp.Or(
+				cmp.Compare(a.Price.Amount, b.Price.Amount),
+				cmp.Compare(a.Provider, b.Provider),
+				cmp.Compare(a.ID, b.ID),
+			)
+		})
+
+		primary := offers[0]
+		for _, offer := range offers[1:] {
+			primary.AlternativeOffers = append(primary.AlternativeOffers, models.AlternativeOffer{
+				ID:           offer.ID,
+				Provider:     offer.Provider,
+				Airline:      offer.Airline,
+				FlightNumber: offer.FlightNumber,
+				Price:        offer.Price,
+				Pricing:      offer.Pricing,
+			})
+		}
+
+		dedupedFlights = append(dedupedFlights, primary)
+	}
+
+	merged := len(*flights) - len(dedupedFlights)
+	*flights = dedupedFlights
+
+	return merged
+}
+
+var flightNumberSeparators = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// Identify the physical flight by operating airline, flight number and
+// departure time, so the same flight from different feeds shares a key and a
+// codeshare matches the flight of the airline that operates it
+func GetOperatingFlightKey(flight models.Flight) string {
+	airline, flightNumber := flight.Airline, flight.FlightNumber
+	if flight.OperatingCarrier != nil {
+		airline, flightNumber = flight.OperatingCarrier.Airline, flight.OperatingCarrier.FlightNumber
+	}
+
+	return fmt.Sprintf("%s|%s|%d",
+		strings.ToUpper(airline.Code),
+		normalizeFlightNumber(flightNumber, airline.Code),
+		flight.Departure.Timestamp,
+	)
+}
+
+// Strip separators, the airline designator and leading zeros: "GA 0400" -> "400"
+func normalizeFlightNumber(flightNumber string, airlineCode string) string {
+	normalized := flightNumberSeparators.ReplaceAllString(strings.ToUpper(flightNumber), "")
+	normalized = strings.TrimPrefix(normalized, strings.ToUpper(airlineCode))
+	return strings.TrimLeft(normalized, "0")
+}
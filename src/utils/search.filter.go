@@ -3,7 +3,9 @@ package utils
 import (
 	"bookcabin-app-go/src/constants"
 	"bookcabin-app-go/src/models"
+	"bookcabin-app-go/src/references"
 	"slices"
+	"strings"
 	"time"
 )
 
@@ -12,11 +14,11 @@ func ApplySearchFilters(flights *[]models.Flight, req models.SearchRequest) {
 
 	for _, flight := range *flights {
 
-		if req.Filters.PriceMin > 0 && flight.Price.Amount < req.Filters.PriceMin {
+		if req.Filters.PriceMin > 0 && GetEffectivePrice(flight) < req.Filters.PriceMin {
 			continue
 		}
 
-		if req.Filters.PriceMax > 0 && flight.Price.Amount > req.Filters.PriceMax {
+		if req.Filters.PriceMax > 0 && GetEffectivePrice(flight) > req.Filters.PriceMax {
 			continue
 		}
 
@@ -24,8 +26,7 @@ func ApplySearchFilters(flights *[]models.Flight, req models.SearchRequest) {
 			continue
 		}
 
-		isAirlinePresent := slices.Contains(req.Filters.Airlines, flight.Airline.Name)
-		if len(req.Filters.Airlines) > 0 && !isAirlinePresent {
+		if len(req.Filters.Airlines) > 0 && !matchesAirline(flight.Airline, req.Filters.Airlines) {
 			continue
 		}
 
@@ -55,8 +56,103 @@ func ApplySearchFilters(flights *[]models.Flight, req models.SearchRequest) {
 			}
 		}
 
+		if !matchesLayoverFilters(flight, req.Filters) {
+			continue
+		}
+
+		if req.Filters.RefundableOnly && !flight.Refundable {
+			continue
+		}
+
+		if req.Filters.CheckedBagIncluded && !flight.Baggage.CheckedIncluded {
+			continue
+		}
+
 		filteredFlights = append(filteredFlights, flight)
 	}
 
 	*flights = filteredFlights
 }
+
+// Airlines can be filtered by IATA or ICAO code, canonical name or a known alias
+// of the flight's own airline, so a name shared by several airlines matches each
+func matchesAirline(airline models.Airline, airlines []string) bool {
+	ref, known := references.GetAirline(airline.Code)
+
+	for _, query := range airlines {
+		if strings.EqualFold(query, airline.Code) ||
+			strings.EqualFold(query, airline.ICAO) ||
+			strings.EqualFold(query, airline.Name) {
+			return true
+		}
+
+		if known && references.MatchesAirline(ref, query) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesLayoverFilters(flight models.Flight, filters models.Filters) bool {
+	totalLayoverMinutes := 0
+	hasRequiredConnection := false
+
+	for i, layover := range flight.Layovers {
+		totalLayoverMinutes += layover.DurationMinutes
+
+		if filters.MaxLayoverMinutes > 0 && layover.DurationMinutes > filters.MaxLayoverMinutes {
+			return false
+		}
+
+		if filters.MinConnectionMinutes > 0 && layover.DurationMinutes < filters.MinConnectionMinutes {
+			return false
+		}
+
+		if slices.Contains(filters.ExcludedConnectionAirports, layover.Airport) {
+			return false
+		}
+
+		if slices.Contains(filters.RequiredConnectionAirports, layover.Airport) {
+			hasRequiredConnection = true
+		}
+
+		if filters.ExcludeOvernightLayovers && isOvernightLayover(flight, i) {
+			return false
+		}
+	}
+
+	if filters.MaxTotalLayoverMinutes > 0 && totalLayoverMinutes > filters.MaxTotalLayoverMinutes {
+		return false
+	}
+
+	if len(filters.RequiredConnectionAirports) > 0 && !hasRequiredConnection {
+		return false
+	}
+
+	return true
+}
+
+// A layover is overnight when it crosses midnight at the connecting airport.
+// Layovers whose leg times the provider did not report are not treated as
+// overnight, since the journey crossing midnight says nothing about the stop.
+func isOvernightLayover(flight models.Flight, layoverIdx int) bool {
+	if layoverIdx+1 >= len(flight.Segments) {
+		return false
+	}
+
+	arrivalDate := getLocalDate(flight.Segments[layoverIdx].Arrival.DateTime)
+	departureDate := getLocalDate(flight.Segments[layoverIdx+1].Departure.DateTime)
+	if arrivalDate == "" || departureDate == "" {
+		return false
+	}
+
+	return arrivalDate != departureDate
+}
+
+func getLocalDate(dateTime string) string {
+	parsed, err := time.Parse(constants.GA_DateTimeLayout, dateTime)
+	if err != nil {
+		return ""
+	}
+	return parsed.Format(time.DateOnly)
+}
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"bookcabin-app-go/src/models"
+)
+
+const (
+	LabelCheapest        = "cheapest"
+	LabelFastest         = "fastest"
+	LabelEarliestArrival = "earliest_arrival"
+	LabelBestValue       = "best_value"
+)
+
+// Mark flights on the price-versus-duration Pareto frontier of every flight found
+func MarkParetoOptimal(flights []models.Flight) {
+	for i, flight := range flights {
+		flights[i].ParetoOptimal = !isDominated(flight, flights)
+	}
+}
+
+// Label the cheapest, fastest, earliest-arriving and best value flights and
+// list the Pareto-optimal ones. Call this on the flights that are returned,
+// after every flight has been dropped, so highlights never name a missing
+// flight. Ties go to the flight that comes first, so call this after sorting.
+func ApplySearchHighlights(flights []models.Flight, weights models.ScoringWeights) models.Highlights {
+	highlights := models.Highlights{ParetoOptimal: []string{}}
+
+	for i := range flights {
+		flights[i].Labels = nil
+	}
+
+	if len(flights) == 0 {
+		return highlights
+	}
+
+	scoreResults := GetBestValueScores(flights, weights)
+	cheapest, fastest, earliestArrival, bestValue := 0, 0, 0, 0
+
+	for i, flight := range flights {
+		if GetEffectivePrice(flight) < GetEffectivePrice(flights[cheapest]) {
+			cheapest = i
+		}
+
+		if flight.Duration.TotalMinutes < flights[fastest].Duration.TotalMinutes {
+			fastest = i
+		}
+
+		if flight.Arrival.Timestamp < flights[earliestArrival].Arrival.Timestamp {
+			earliestArrival = i
+		}
+
+		if scoreResults[flight.ID].Total() > scoreResults[flights[bestValue].ID].Total() {
+			bestValue = i
+		}
+
+		if flight.ParetoOptimal {
+			highlights.ParetoOptimal = append(highlights.ParetoOptimal, flight.ID)
+		}
+	}
+
+	highlights.Cheapest = labelFlight(&flights[cheapest], LabelCheapest)
+	highlights.Fastest = labelFlight(&flights[fastest], LabelFastest)
+	highlights.EarliestArrival = labelFlight(&flights[earliestArrival], LabelEarliestArrival)
+	highlights.BestValue = labelFlight(&flights[bestValue], LabelBestValue)
+
+	return highlights
+}
+
+// Drop flights that another flight beats on both price and duration
+func KeepParetoOptimal(flights *[]models.Flight) {
+	paretoFlights := []models.Flight{}
+
+	for _, flight := range *flights {
+		if flight.ParetoOptimal {
+			paretoFlights = append(paretoFlights, flight)
+		}
+	}
+
+	*flights = paretoFlights
+}
+
+// A flight is dominated when another flight is no worse on price and duration,
+// and strictly better on at least one of them
+func isDominated(flight models.Flight, flights []models.Flight) bool {
+	for _, other := range flights {
+		noWorse := GetEffectivePrice(other) <= GetEffectivePrice(flight) &&
+			other.Duration.TotalMinutes <= flight.Duration.TotalMinutes
+		better := GetEffectivePrice(other) < GetEffectivePrice(flight) ||
+			other.Duration.TotalMinutes < flight.Duration.TotalMinutes
+
+		if noWorse && better {
+			return true
+		}
+	}
+	return false
+}
+
+func labelFlight(flight *models.Flight, label string) *string {
+	flight.Labels = append(flight.Labels, label)
+	id := flight.ID
+	return &id
+}
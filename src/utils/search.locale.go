@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"bookcabin-app-go/src/constants"
+	"bookcabin-app-go/src/models"
+	"bookcabin-app-go/src/references"
+	"fmt"
+	"time"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+type localeFormat struct {
+	tag           language.Tag
+	symbolSpacing string
+	hourUnit      string
+	minuteUnit    string
+	weekdays      [7]string
+	months        [12]string
+}
+
+var localeFormats = map[string]localeFormat{
+	"en": {
+		tag:           language.English,
+		symbolSpacing: "",
+		hourUnit:      "h",
+		minuteUnit:    "m",
+		weekdays:      [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+		months:        [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+	},
+	"id": {
+		tag:           language.Indonesian,
+		symbolSpacing: " ",
+		hourUnit:      " jam",
+		minuteUnit:    " mnt",
+		weekdays:      [7]string{"Min", "Sen", "Sel", "Rab", "Kam", "Jum", "Sab"},
+		months:        [12]string{"Jan", "Feb", "Mar", "Apr", "Mei", "Jun", "Jul", "Agu", "Sep", "Okt", "Nov", "Des"},
+	},
+}
+
+func getLocaleFormat(locale string) localeFormat {
+	if format, ok := localeFormats[locale]; ok {
+		return format
+	}
+	return localeFormats[constants.DefaultLocale]
+}
+
+// Re-render every human readable field of the flights in the given locale
+func LocalizeFlights(flights []models.Flight, locale string) {
+	for i := range flights {
+		flight := &flights[i]
+
+		localizePrice(&flight.Price, locale)
+		if flight.DiscountedPrice != nil {
+			localizePrice(flight.DiscountedPrice, locale)
+		}
+		if flight.AllInPrice != nil {
+			localizePrice(flight.AllInPrice, locale)
+		}
+		if flight.Discount != nil {
+			flight.Discount.Formatted = FormatLocalizedPrice(flight.Discount.Amount, flight.Discount.Currency, locale)
+		}
+		for j := range flight.AlternativeOffers {
+			localizePrice(&flight.AlternativeOffers[j].Price, locale)
+		}
+
+		flight.Duration.Formatted = FormatLocalizedDuration(flight.Duration.TotalMinutes, locale)
+		localizeEventPoint(&flight.Departure, locale)
+		localizeEventPoint(&flight.Arrival, locale)
+
+		for j := range flight.Segments {
+			segment := &flight.Segments[j]
+			localizeEventPoint(&segment.Departure, locale)
+			localizeEventPoint(&segment.Arrival, locale)
+
+			if segment.Duration != nil {
+				segment.Duration.Formatted = FormatLocalizedDuration(segment.Duration.TotalMinutes, locale)
+			}
+		}
+	}
+}
+
+func localizePrice(price *models.Price, locale string) {
+	price.Formatted = FormatLocalizedPrice(price.Amount, price.Currency, locale)
+}
+
+func localizeEventPoint(point *models.EventPoint, locale string) {
+	if city, ok := references.GetCityName(point.Airport, locale); ok {
+		point.City = city
+	}
+	point.DisplayDateTime = FormatLocalizedDateTime(point.DateTime, locale)
+}
+
+// Group digits the locale's way and put the currency symbol in front:
+// "Rp 1.250.000" in Indonesian, "Rp1,250,000" in English
+func FormatLocalizedPrice(price float64, currency string, locale string) string {
+	format := getLocaleFormat(locale)
+	p := message.NewPrinter(format.tag)
+	formattedPrice := p.Sprintf("%.*f", GetCurrencyDecimals(currency), price)
+
+	symbol, ok := constants.CurrencySymbols[currency]
+	if !ok {
+		return currency + " " + formattedPrice
+	}
+	return symbol + format.symbolSpacing + formattedPrice
+}
+
+// "1h 45m" in English, "1 jam 45 mnt" in Indonesian
+func FormatLocalizedDuration(durationInMin int, locale string) string {
+	format := getLocaleFormat(locale)
+	hours, minutes := durationInMin/60, durationInMin%60
+
+	if hours == 0 {
+		return fmt.Sprintf("%d%s", minutes, format.minuteUnit)
+	}
+	return fmt.Sprintf("%d%s %d%s", hours, format.hourUnit, minutes, format.minuteUnit)
+}
+
+// "Mon, 15 Dec 2025 06:00" in English, "Sen, 15 Des 2025 06:00" in Indonesian, in the airport's local time
+func FormatLocalizedDateTime(dateTime string, locale string) string {
+	parsed, err := time.Parse(constants.GA_DateTimeLayout, dateTime)
+	if err != nil {
+		return ""
+	}
+
+	format := getLocaleFormat(locale)
+	return fmt.Sprintf("%s, %02d %s %d %s",
+		format.weekdays[parsed.Weekday()],
+		parsed.Day(),
+		format.months[parsed.Month()-1],
+		parsed.Year(),
+		parsed.Format("15:04"),
+	)
+}
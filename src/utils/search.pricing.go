@@ -0,0 +1,253 @@
+package utils
+
+import (
+	"bookcabin-app-go/src/constants"
+	"bookcabin-app-go/src/libs"
+	"bookcabin-app-go/src/models"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/* Markup and commission rules engine, reloaded from config whenever the file changes */
+const (
+	PricingRuleMarkup     = "markup"
+	PricingRuleCommission = "commission"
+)
+
+var (
+	pricingRules          []models.PricingRule
+	pricingRulesModTime   time.Time
+	pricingRulesCheckedAt time.Time
+	pricingRulesMutex     sync.Mutex
+)
+
+// Current pricing rules. The config file's modification time is checked at most
+// once per reload interval; a malformed file keeps the last valid rules and a
+// missing file disables the rules.
+func GetPricingRules() []models.PricingRule {
+	pricingRulesMutex.Lock()
+	defer pricingRulesMutex.Unlock()
+
+	intervalInSeconds, _ := strconv.Atoi(libs.GetEnv("PRICING_RULES_RELOAD_INTERVAL_IN_S", "5"))
+	if !pricingRulesCheckedAt.IsZero() && time.Since(pricingRulesCheckedAt) < time.Duration(intervalInSeconds)*time.Second {
+		return pricingRules
+	}
+	pricingRulesCheckedAt = time.Now()
+
+	file := libs.GetEnv("PRICING_RULES_FILE", "pricing_rules.json")
+	info, err := libs.StatConfigFile(file)
+	if err != nil {
+		pricingRules, pricingRulesModTime = nil, time.Time{}
+		return pricingRules
+	}
+
+	if info.ModTime().Equal(pricingRulesModTime) {
+		return pricingRules
+	}
+
+	data, err := libs.ReadConfigFile(file)
+	if err != nil {
+		return pricingRules
+	}
+
+	var config models.PricingRules
+	if err := json.Unmarshal(data, &config); err != nil || validatePricingRules(config.Rules) != nil {
+		return pricingRules
+	}
+
+	pricingRules, pricingRulesModTime = config.Rules, info.ModTime()
+	return pricingRules
+}
+
+func validatePricingRules(rules []models.PricingRule) error {
+	for _, rule := range rules {
+		if rule.Type != PricingRuleMarkup && rule.Type != PricingRuleCommission {
+			return fmt.Errorf("pricing rule %s has unknown type %s", rule.Name, rule.Type)
+		}
+
+		if rule.Currency != "" {
+			if _, ok := constants.CurrencyDecimals[rule.Currency]; !ok {
+				return fmt.Errorf("pricing rule %s has unsupported currency %s", rule.Name, rule.Currency)
+			}
+		}
+	}
+	return nil
+}
+
+// Turn every provider fare into a selling price. Rules are checked in file order,
+// the first matching markup and the first matching commission apply. Markups raise
+// the selling price, commissions lower the net fare paid to the airline.
+// Rates are only requested when a fixed amount is in another currency, and a rule
+// whose amount can't be converted is skipped, and logged, so the next matching
+// rule applies.
+func ApplyPricingRules(
+	flights []models.Flight,
+	req models.SearchRequest,
+	getRates func() (models.FxRates, error),
+) {
+	rules := GetPricingRules()
+	skipped := make(map[string]error)
+
+	for i := range flights {
+		applyPricingRules(&flights[i], rules, req, getRates, skipped)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(skipped)) {
+		log.Printf("pricing rule %s skipped: %v", name, skipped[name])
+	}
+}
+
+func applyPricingRules(
+	flight *models.Flight,
+	rules []models.PricingRule,
+	req models.SearchRequest,
+	getRates func() (models.FxRates, error),
+	skipped map[string]error,
+) {
+	fare, currency := flight.Price.Amount, flight.Price.Currency
+	daysToDeparture := getDaysToDeparture(*flight)
+
+	breakdown := models.PricingBreakdown{
+		ProviderFare: fare,
+		Currency:     currency,
+		AppliedRules: []string{},
+	}
+	applied := make(map[string]bool)
+
+	for _, rule := range rules {
+		if applied[rule.Type] || !matchesPricingRule(rule, *flight, req, daysToDeparture) {
+			continue
+		}
+
+		amount, err := getPricingRuleAmount(rule, fare, currency, getRates)
+		if err != nil {
+			skipped[rule.Name] = err
+			continue
+		}
+
+		if rule.Type == PricingRuleMarkup {
+			breakdown.Markup = amount
+		} else {
+			breakdown.Commission = amount
+		}
+
+		applied[rule.Type] = true
+		breakdown.AppliedRules = append(breakdown.AppliedRules, rule.Name)
+	}
+
+	breakdown.NetFare = RoundAmount(fare-breakdown.Commission, currency)
+	breakdown.SellingPrice = RoundAmount(fare+breakdown.Markup, currency)
+
+	flight.Price.Amount = breakdown.SellingPrice
+	flight.Price.Formatted = FormatPrice(breakdown.SellingPrice, currency)
+	flight.Pricing = &breakdown
+}
+
+func matchesPricingRule(rule models.PricingRule, flight models.Flight, req models.SearchRequest, daysToDeparture int) bool {
+	if len(rule.Airlines) > 0 && !slices.Contains(rule.Airlines, flight.Airline.Code) {
+		return false
+	}
+
+	if len(rule.Routes) > 0 && !slices.ContainsFunc(rule.Routes, func(route string) bool {
+		return matchesRoute(route, flight.Departure.Airport, flight.Arrival.Airport)
+	}) {
+		return false
+	}
+
+	if len(rule.CabinClasses) > 0 && !slices.Contains(rule.CabinClasses, flight.CabinClass) {
+		return false
+	}
+
+	if len(rule.Providers) > 0 && !slices.Contains(rule.Providers, flight.Provider) {
+		return false
+	}
+
+	if len(rule.Channels) > 0 && !slices.Contains(rule.Channels, req.Channel) {
+		return false
+	}
+
+	if rule.MinDaysToDeparture != nil && daysToDeparture < *rule.MinDaysToDeparture {
+		return false
+	}
+
+	if rule.MaxDaysToDeparture != nil && daysToDeparture > *rule.MaxDaysToDeparture {
+		return false
+	}
+
+	return true
+}
+
+// Routes are written as "CGK-DPS", "*" matches any airport on that side
+func matchesRoute(route string, origin string, destination string) bool {
+	routeOrigin, routeDestination, ok := strings.Cut(route, "-")
+	if !ok {
+		return false
+	}
+
+	return (routeOrigin == "*" || routeOrigin == origin) &&
+		(routeDestination == "*" || routeDestination == destination)
+}
+
+// Percentage of the fare plus the fixed amount, converted from the rule's currency
+func getPricingRuleAmount(
+	rule models.PricingRule,
+	fare float64,
+	currency string,
+	getRates func() (models.FxRates, error),
+) (float64, error) {
+	amount := fare * rule.Percent / 100
+
+	if rule.FixedAmount != 0 {
+		fixedAmount := rule.FixedAmount
+
+		if ruleCurrency := cmp.Or(rule.Currency, currency); ruleCurrency != currency {
+			rates, err := getRates()
+			if err != nil {
+				return 0, err
+			}
+
+			rate, err := rates.Rate(ruleCurrency, currency)
+			if err != nil {
+				return 0, err
+			}
+			fixedAmount *= rate
+		}
+
+		amount += fixedAmount
+	}
+
+	return RoundAmount(amount, currency), nil
+}
+
+// Whole days between today and the departure date, both at the departure airport
+func getDaysToDeparture(flight models.Flight) int {
+	departure, err := time.Parse(constants.GA_DateTimeLayout, flight.Departure.DateTime)
+	if err != nil {
+		return 0
+	}
+
+	today := time.Now().In(departure.Location())
+	departureDate := time.Date(departure.Year(), departure.Month(), departure.Day(), 0, 0, 0, 0, time.UTC)
+	todayDate := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+
+	return int(departureDate.Sub(todayDate).Hours() / 24)
+}
+
+// Drop pricing breakdowns, public callers only see the selling price
+func HidePricingBreakdowns(flights []models.Flight) {
+	for i := range flights {
+		flights[i].Pricing = nil
+
+		for j := range flights[i].AlternativeOffers {
+			flights[i].AlternativeOffers[j].Pricing = nil
+		}
+	}
+}
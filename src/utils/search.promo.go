@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"bookcabin-app-go/src/constants"
+	"bookcabin-app-go/src/libs"
+	"bookcabin-app-go/src/models"
+	"cmp"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+/* Promo codes, discounting the selling price of eligible flights */
+const (
+	PromoStatusApplied           = "applied"
+	PromoStatusInvalid           = "invalid"
+	PromoStatusNotStarted        = "not_started"
+	PromoStatusExpired           = "expired"
+	PromoStatusUsageLimitReached = "usage_limit_reached"
+	PromoStatusNotEligible       = "not_eligible"
+	PromoStatusUnavailable       = "unavailable"
+)
+
+var (
+	promotions     map[string]models.Promotion
+	promotionsOnce sync.Once
+)
+
+// Load promotions once from config, keyed by their uppercase code
+func GetPromotions() map[string]models.Promotion {
+	promotionsOnce.Do(func() {
+		promotions = make(map[string]models.Promotion)
+
+		data, err := libs.ReadConfigFile(libs.GetEnv("PROMOTIONS_FILE", "promotions.json"))
+		if err != nil {
+			return
+		}
+
+		var config models.Promotions
+		if err := json.Unmarshal(data, &config); err != nil {
+			return
+		}
+
+		for _, promotion := range config.Promotions {
+			promotions[strings.ToUpper(promotion.Code)] = promotion
+		}
+	})
+
+	return promotions
+}
+
+func GetPromotion(code string) (models.Promotion, bool) {
+	promotion, ok := GetPromotions()[strings.ToUpper(code)]
+	return promotion, ok
+}
+
+// Status of the promo's sale period at the given time, empty when it can be used
+func GetPromotionPeriodStatus(promotion models.Promotion, now time.Time) string {
+	if validFrom, err := time.Parse(time.RFC3339, promotion.ValidFrom); err == nil && now.Before(validFrom) {
+		return PromoStatusNotStarted
+	}
+
+	if validUntil, err := time.Parse(time.RFC3339, promotion.ValidUntil); err == nil && now.After(validUntil) {
+		return PromoStatusExpired
+	}
+
+	return ""
+}
+
+// Discount every eligible flight, returning how many were discounted. The minimum
+// spend and the maximum discount count for all passengers together, the discount
+// shown on the flight is per passenger like its price.
+func ApplyPromotion(
+	flights []models.Flight,
+	promotion models.Promotion,
+	req models.SearchRequest,
+	getRates func() (models.FxRates, error),
+) (int, error) {
+	var eligible int
+
+	for i := range flights {
+		flight := &flights[i]
+		currency := flight.Price.Currency
+		passengers := max(req.Passengers, 1)
+
+		if !matchesPromotion(promotion, *flight) {
+			continue
+		}
+
+		rate := 1.0
+		if promoCurrency := cmp.Or(promotion.Currency, currency); promoCurrency != currency {
+			rates, err := getRates()
+			if err != nil {
+				return eligible, err
+			}
+
+			rate, err = rates.Rate(promoCurrency, currency)
+			if err != nil {
+				return eligible, err
+			}
+		}
+
+		total := flight.Price.Amount * float64(passengers)
+		if total < promotion.MinSpend*rate {
+			continue
+		}
+
+		totalDiscount := total*promotion.Percent/100 + promotion.FixedAmount*rate
+		if promotion.MaxDiscount > 0 {
+			totalDiscount = min(totalDiscount, promotion.MaxDiscount*rate)
+		}
+		totalDiscount = min(totalDiscount, total)
+
+		discount := RoundAmount(totalDiscount/float64(passengers), currency)
+		if discount <= 0 {
+			continue
+		}
+
+		discountedAmount := RoundAmount(flight.Price.Amount-discount, currency)
+		flight.DiscountedPrice = &models.Price{
+			Amount:    discountedAmount,
+			Currency:  currency,
+			Formatted: FormatPrice(discountedAmount, currency),
+		}
+		flight.Discount = &models.Discount{
+			Code:        strings.ToUpper(promotion.Code),
+			Description: promotion.Description,
+			Amount:      discount,
+			Currency:    currency,
+			Formatted:   FormatPrice(discount, currency),
+		}
+		eligible++
+	}
+
+	return eligible, nil
+}
+
+func matchesPromotion(promotion models.Promotion, flight models.Flight) bool {
+	if len(promotion.Airlines) > 0 && !slices.Contains(promotion.Airlines, flight.Airline.Code) {
+		return false
+	}
+
+	if len(promotion.Routes) > 0 && !slices.ContainsFunc(promotion.Routes, func(route string) bool {
+		return matchesRoute(route, flight.Departure.Airport, flight.Arrival.Airport)
+	}) {
+		return false
+	}
+
+	// travel dates are compared with the local departure date
+	departureDate := flight.Departure.DateTime
+	if departure, err := time.Parse(constants.GA_DateTimeLayout, flight.Departure.DateTime); err == nil {
+		departureDate = departure.Format(time.DateOnly)
+	}
+
+	if promotion.TravelDateFrom != "" && departureDate < promotion.TravelDateFrom {
+		return false
+	}
+
+	if promotion.TravelDateTo != "" && departureDate > promotion.TravelDateTo {
+		return false
+	}
+
+	return true
+}
+
+// Price the passenger pays: the discounted price when a promo applies, used by
+// filters, sorting, scoring and highlights
+func GetEffectivePrice(flight models.Flight) float64 {
+	if flight.DiscountedPrice != nil {
+		return flight.DiscountedPrice.Amount
+	}
+	return flight.Price.Amount
+}
@@ -1,46 +1,110 @@
 package utils
 
 import (
+	"bookcabin-app-go/src/libs"
 	"bookcabin-app-go/src/models"
+	"encoding/json"
+	"maps"
+	"math"
 	"slices"
-	"strings"
+	"sync"
 )
 
-/* Flight Best Value algorithm using simple Weighted Scoring Model */
-const (
-	price_duration_stop     = .8
-	amenitis_checkedbaggage = .2
+/* Flight Best Value algorithm using Weighted Scoring Model with configurable profiles */
+const DefaultScoringProfile = "balanced"
+
+var defaultScoringWeights = models.ScoringWeights{
+	Price:          .8,
+	Duration:       .8,
+	Stops:          .8,
+	Amenities:      .2,
+	CheckedBaggage: .2,
+}
+
+var (
+	scoringProfiles     map[string]models.ScoringWeights
+	scoringProfilesOnce sync.Once
 )
 
 type SearchResultNormalizer struct {
-	MinPrice    int
-	MaxPrice    int
+	MinPrice    float64
+	MaxPrice    float64
 	MinDuration int
 	MaxDuration int
 	MinStop     int
 	MaxStop     int
 	MinAmenity  int
 	MaxAmenity  int
+
+	// prices are all-in, flights without an all-in price score the lowest on price
+	AllInPricing bool
+}
+
+// Load scoring profiles once from config, keeping the built-in "balanced"
+// profile available when the config file is missing or malformed
+func GetScoringProfiles() map[string]models.ScoringWeights {
+	scoringProfilesOnce.Do(func() {
+		scoringProfiles = map[string]models.ScoringWeights{
+			DefaultScoringProfile: defaultScoringWeights,
+		}
+
+		data, err := libs.ReadConfigFile(libs.GetEnv("SCORING_PROFILES_FILE", "scoring_profiles.json"))
+		if err != nil {
+			return
+		}
+
+		var profiles map[string]models.ScoringWeights
+		if err := json.Unmarshal(data, &profiles); err != nil {
+			return
+		}
+
+		maps.Copy(scoringProfiles, profiles)
+	})
+
+	return scoringProfiles
+}
+
+// Custom weights on the request win over the named profile
+func GetScoringWeights(req models.SearchRequest) models.ScoringWeights {
+	if req.ScoringWeights != nil {
+		return *req.ScoringWeights
+	}
+
+	if weights, ok := GetScoringProfiles()[req.ScoringProfile]; ok {
+		return weights
+	}
+
+	return GetScoringProfiles()[DefaultScoringProfile]
 }
 
 // Normalize value into float64 ranged from 0.00 to 1.00
-func norm(value int, min int, max int) float64 {
+func norm[T int | float64](value T, min T, max T) float64 {
 	if min == max {
 		return 0
 	}
 	return float64(value-min) / float64(max-min)
 }
 
-func GetBestValueScores(flights []models.Flight) map[string]float64 {
-	var prices, durations, stops, amenities []int
-	results := make(map[string]float64)
+func GetBestValueScores(
+	flights []models.Flight,
+	weights models.ScoringWeights,
+) map[string]models.ScoreBreakdown {
+	var prices []float64
+	var durations, stops, amenities []int
+	results := make(map[string]models.ScoreBreakdown)
 
 	if len(flights) == 0 {
 		return results
 	}
 
+	allInPricing := slices.ContainsFunc(flights, func(flight models.Flight) bool {
+		return flight.AllInPrice != nil
+	})
+
 	for _, flight := range flights {
-		prices = append(prices, flight.Price.Amount)
+		if !allInPricing || flight.AllInPrice != nil {
+			prices = append(prices, GetScoringPrice(flight))
+		}
 		durations = append(durations, flight.Duration.TotalMinutes)
 		stops = append(stops, flight.Stops)
 
@@ -63,10 +127,12 @@ func GetBestValueScores(flights []models.Flight) map[string]float64 {
 		MaxStop:     slices.Max(stops),
 		MinAmenity:  slices.Min(amenities),
 		MaxAmenity:  slices.Max(amenities),
+
+		AllInPricing: allInPricing,
 	}
 
 	for _, flight := range flights {
-		results[flight.ID] = CalculateFlightScore(flight, normalizer)
+		results[flight.ID] = CalculateFlightScore(flight, normalizer, weights)
 	}
 
 	return results
@@ -74,35 +140,55 @@ func GetBestValueScores(flights []models.Flight) map[string]float64 {
 
 func CalculateFlightScore(flight models.Flight,
 	normalizer SearchResultNormalizer,
-) float64 {
+	weights models.ScoringWeights,
+) models.ScoreBreakdown {
 	// Positive values: amenities, free checked baggage
 	// Negative values: price, duration, stop
-	var positive, negative float64
+	totalWeight := weights.Total()
+	if totalWeight <= 0 {
+		return models.ScoreBreakdown{}
+	}
 
-	// add amenities point
+	var amenitiesPoint float64
 	if flight.Amenities != nil {
-		positive += norm(len(*flight.Amenities), normalizer.MinAmenity, normalizer.MaxAmenity)
+		amenitiesPoint = norm(len(*flight.Amenities), normalizer.MinAmenity, normalizer.MaxAmenity)
+	}
+
+	// a bare fare can't be compared with all-in prices, so it scores as the dearest
+	pricePoint := 1 - norm(GetScoringPrice(flight), normalizer.MinPrice, normalizer.MaxPrice)
+	if normalizer.AllInPricing && flight.AllInPrice == nil {
+		pricePoint = 0
 	}
 
 	freeCheckedBaggagePoint := 0
-	if flight.Baggage.Checked != nil {
-		checkedBaggageInfo := flight.Baggage.Checked
-		if !strings.Contains(strings.ToLower(*checkedBaggageInfo), "additional fee") {
-			freeCheckedBaggagePoint = 1
-		}
+	if flight.Baggage.CheckedIncluded {
+		freeCheckedBaggagePoint = 1
 	}
-	// add free checked baggage point
-	positive += norm(freeCheckedBaggagePoint, 0, 1)
 
-	// calc. price
-	negative += norm(flight.Price.Amount, normalizer.MinPrice, normalizer.MaxPrice)
+	// negative values are inverted so every factor reads "higher is better",
+	// then weighted and scaled so the total score stays within 0.00 to 1.00
+	return models.ScoreBreakdown{
+		Price:          weighScore(pricePoint, weights.Price, totalWeight),
+		Duration:       weighScore(1-norm(flight.Duration.TotalMinutes, normalizer.MinDuration, normalizer.MaxDuration), weights.Duration, totalWeight),
+		Stops:          weighScore(1-norm(flight.Stops, normalizer.MinStop, normalizer.MaxStop), weights.Stops, totalWeight),
+		Amenities:      weighScore(amenitiesPoint, weights.Amenities, totalWeight),
+		CheckedBaggage: weighScore(norm(freeCheckedBaggagePoint, 0, 1), weights.CheckedBaggage, totalWeight),
+	}
+}
 
-	// calc. duration
-	negative += norm(flight.Duration.TotalMinutes, normalizer.MinDuration, normalizer.MaxDuration)
+func weighScore(point float64, weight float64, totalWeight float64) float64 {
+	return math.Round(point*weight/totalWeight*10000) / 10000
+}
+
+// Attach the score and its breakdown to each flight for the response
+func ApplyFlightScores(flights []models.Flight, weights models.ScoringWeights) {
+	scoreResults := GetBestValueScores(flights, weights)
 
-	// calc. # of stops
-	negative += norm(flight.Stops, normalizer.MinStop, normalizer.MaxStop)
+	for i := range flights {
+		breakdown := scoreResults[flights[i].ID]
+		score := math.Round(breakdown.Total()*10000) / 10000
 
-	// apply weight
-	return positive*amenitis_checkedbaggage + (1-negative)*price_duration_stop
+		flights[i].Score = &score
+		flights[i].ScoreBreakdown = &breakdown
+	}
 }
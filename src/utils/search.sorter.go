@@ -1,69 +1,81 @@
 package utils
 
 import (
-	"bookcabin-app-go/src/constants"
 	"bookcabin-app-go/src/models"
+	"cmp"
+	"slices"
+)
 
-	"sort"
-	"time"
+const (
+	SortByBestValue = "best_value"
+	SortByPrice     = "price"
+	SortByDuration  = "duration"
+	SortByDeparture = "departure"
+	SortByArrival   = "arrival"
 )
 
-func ApplySearchSorter(flights []models.Flight, sortBy string, sortOrder string) {
+// Direction used when neither the key nor sortOrder sets one, bigger score = better value
+var DefaultSortOrders = map[string]string{
+	SortByBestValue: "desc",
+	SortByPrice:     "asc",
+	SortByDuration:  "asc",
+	SortByDeparture: "asc",
+	SortByArrival:   "asc",
+}
+
+func ApplySearchSorter(
+	flights []models.Flight,
+	sortKeys models.SortKeys,
+	weights models.ScoringWeights,
+) {
 	if len(flights) == 0 {
 		return
 	}
 
-	if sortBy == "best_value" {
-		scoreResults := GetBestValueScores(flights)
-
-		sort.Slice(flights, func(i, j int) bool {
-			scoreI := scoreResults[flights[i].ID]
-			scoreJ := scoreResults[flights[j].ID]
-
-			// force descending sort bigger score = better value
-			return scoreJ > scoreI
-		})
-		return
+	var scoreResults map[string]models.ScoreBreakdown
+	if slices.ContainsFunc(sortKeys, func(k models.SortKey) bool { return k.Key == SortByBestValue }) {
+		scoreResults = GetBestValueScores(flights, weights)
 	}
 
-	sort.Slice(flights, func(i, j int) bool {
-		var compareVal int
+	slices.SortStableFunc(flights, func(a, b models.Flight) int {
+		for _, sortKey := range sortKeys {
+			compareVal := compareFlights(a, b, sortKey.Key, scoreResults)
 
-		switch sortBy {
-		case "price":
-			if flights[i].Price.Amount < flights[j].Price.Amount {
-				compareVal = -1
-			} else if flights[i].Price.Amount > flights[j].Price.Amount {
-				compareVal = 1
-			}
-		case "duration":
-			if flights[i].Duration.TotalMinutes < flights[j].Duration.TotalMinutes {
-				compareVal = -1
-			} else if flights[i].Duration.TotalMinutes > flights[j].Duration.TotalMinutes {
-				compareVal = 1
+			if sortKey.Order == "desc" {
+				compareVal = compareVal * -1
 			}
-		case "departure":
-			departTimeI, _ := time.Parse(constants.GA_DateTimeLayout, flights[i].Departure.DateTime)
-			departTimeJ, _ := time.Parse(constants.GA_DateTimeLayout, flights[j].Departure.DateTime)
-			if departTimeI.Before(departTimeJ) {
-				compareVal = -1
-			} else if departTimeI.After(departTimeJ) {
-				compareVal = 1
-			}
-		case "arrival":
-			arriveTimeI, _ := time.Parse(constants.GA_DateTimeLayout, flights[i].Arrival.DateTime)
-			arriveTimeJ, _ := time.Parse(constants.GA_DateTimeLayout, flights[j].Arrival.DateTime)
-			if arriveTimeI.Before(arriveTimeJ) {
-				compareVal = -1
-			} else if arriveTimeI.After(arriveTimeJ) {
-				compareVal = 1
-			}
-		}
 
-		if sortOrder == "desc" {
-			compareVal = compareVal * -1
+			if compareVal != 0 {
+				return compareVal
+			}
 		}
 
-		return compareVal < 0
+		// stable final tie-breakers so equal flights keep the same order between calls
+		return cmp.Or(
+			cmp.Compare(a.ID, b.ID),
+			cmp.Compare(a.Provider, b.Provider),
+			cmp.Compare(a.Departure.Timestamp, b.Departure.Timestamp),
+		)
 	})
 }
+
+func compareFlights(
+	a models.Flight,
+	b models.Flight,
+	sortBy string,
+	scoreResults map[string]models.ScoreBreakdown,
+) int {
+	switch sortBy {
+	case SortByBestValue:
+		return cmp.Compare(scoreResults[a.ID].Total(), scoreResults[b.ID].Total())
+	case SortByPrice:
+		return cmp.Compare(GetEffectivePrice(a), GetEffectivePrice(b))
+	case SortByDuration:
+		return cmp.Compare(a.Duration.TotalMinutes, b.Duration.TotalMinutes)
+	case SortByDeparture:
+		return cmp.Compare(a.Departure.Timestamp, b.Departure.Timestamp)
+	case SortByArrival:
+		return cmp.Compare(a.Arrival.Timestamp, b.Arrival.Timestamp)
+	}
+	return 0
+}